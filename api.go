@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -55,7 +56,9 @@ func (ctx *Ctx) CallAction(action string, params Params) APIResponse {
 		Action: action,
 		Params: params,
 	}
+	start := time.Now()
 	rsp, err := ctx.caller.CallAPI(req)
+	observeAPI(action, start, rsp, err)
 	if err != nil {
 		log.Warnln("[api] 调用", action, "时出现错误:", err)
 	}
@@ -20,15 +20,25 @@ import (
 
 // Config is config of zero bot
 type Config struct {
-	NickName        []string      `json:"nickname"`           // 机器人名称
-	CommandPrefix   string        `json:"command_prefix"`     // 触发命令
-	SuperUsers      []int64       `json:"super_users"`        // 超级用户
-	RingLen         uint          `json:"ring_len"`           // 事件环长度 (默认关闭)
-	Latency         time.Duration `json:"latency"`            // 事件处理延迟 (延迟 latency 再处理事件，在 ring 模式下不可低于 1ms)
-	MaxProcessTime  time.Duration `json:"max_process_time"`   // 事件最大处理时间 (默认4min)
-	MarkMessage     bool          `json:"mark_message"`       // 自动标记消息为已读
-	KeepAtMeMessage bool          `json:"keep_at_me_message"` // 是否保留at me的原始消息
-	Driver          []Driver      `json:"-"`                  // 通信驱动
+	NickName          []string            `json:"nickname"`            // 机器人名称
+	CommandPrefix     string              `json:"command_prefix"`      // 触发命令
+	CommandPrefixes   []string            `json:"command_prefixes"`    // 额外的命令前缀, 与 CommandPrefix 同时生效
+	AtMeNoPrefix      bool                `json:"at_me_no_prefix"`     // @机器人 或以昵称开头时命令可省略前缀
+	SuperUsers        []int64             `json:"super_users"`         // 超级用户
+	RingLen           uint                `json:"ring_len"`            // 事件环长度 (默认关闭)
+	Latency           time.Duration       `json:"latency"`             // 事件处理延迟 (延迟 latency 再处理事件，在 ring 模式下不可低于 1ms)
+	MaxProcessTime    time.Duration       `json:"max_process_time"`    // 事件最大处理时间 (默认4min)
+	MarkMessage       bool                `json:"mark_message"`        // 自动标记消息为已读
+	KeepAtMeMessage   bool                `json:"keep_at_me_message"`  // 是否保留at me的原始消息
+	MetricsListen     string              `json:"metrics_listen"`      // Prometheus 指标监听地址, 如 127.0.0.1:9090 (默认关闭)
+	Trace             bool                `json:"trace"`               // 记录每个事件的匹配过程, 可通过 GetEventTrace 获取
+	Fuzzy             FuzzyConfig         `json:"fuzzy"`               // 命令纠错 (默认关闭)
+	MaxPendingFutures int                 `json:"max_pending_futures"` // 每个会话最多等待中的 FutureEvent 数, 超出时取消最早的 (默认不限制)
+	MessageLimit      message.SplitOption `json:"message_limit"`       // 单条消息的长度限制, 供 SendSplit、SendForward 与 LongReply 使用
+	LongReply         LongReplyPolicy     `json:"long_reply"`          // 超出 MessageLimit 的消息的发送方式, 可被群设置覆盖 (默认原样发送)
+	ValidateMessage   bool                `json:"validate_message"`    // 发送前按 OneBot 11 规范校验消息段, 无效时不发送
+	Storage           Storage             `json:"-"`                   // 服务开关、群命令前缀等数据的持久化存储, 如 kv.New("zerobot") (默认仅保存在内存)
+	Driver            []Driver            `json:"-"`                   // 通信驱动
 }
 
 // APICallers 所有的APICaller列表， 通过self-ID映射
@@ -58,6 +68,12 @@ func runinit(op *Config) {
 		op.MaxProcessTime = time.Minute * 4
 	}
 	BotConfig = *op
+	if op.Storage != nil {
+		SetStorage(op.Storage)
+	}
+	if op.MetricsListen != "" {
+		serveMetrics(op.MetricsListen)
+	}
 	if op.RingLen == 0 {
 		return
 	}
@@ -218,11 +234,15 @@ func processEventAsync(response []byte, caller APICaller, maxwait time.Duration)
 	if event.PostType == "message" {
 		preprocessMessageEvent(&event)
 	}
+	metricEvents.With(event.PostType, event.DetailType).Inc()
 	ctx := &Ctx{
 		Event:  &event,
 		State:  State{},
 		caller: &messageLogger{msgid: msgid, caller: caller},
 	}
+	if BotConfig.Trace {
+		ctx.trace = newEventTrace(&event, msgid)
+	}
 	matcherLock.Lock()
 	if hasMatcherListChanged {
 		matcherListForRanging = make([]*Matcher, len(matcherList))
@@ -235,7 +255,7 @@ func processEventAsync(response []byte, caller APICaller, maxwait time.Duration)
 
 // match 匹配规则，处理事件
 func match(ctx *Ctx, matchers []*Matcher, maxwait time.Duration) {
-	if BotConfig.MarkMessage && ctx.Event.MessageID != nil {
+	if BotConfig.MarkMessage && ctx.Event.MessageID != nil && !ctx.fuzzy {
 		ctx.MarkThisMessageAsRead()
 	}
 	gorule := func(rule Rule) <-chan bool {
@@ -267,6 +287,7 @@ func match(ctx *Ctx, matchers []*Matcher, maxwait time.Duration) {
 	}
 	t := time.NewTimer(maxwait)
 	defer t.Stop()
+	consumed := false
 loop:
 	for _, matcher := range matchers {
 		if !matcher.Type(ctx) {
@@ -277,15 +298,19 @@ loop:
 		}
 		m := matcher.copy()
 		ctx.ma = m
+		mt := ctx.trace.begin(m)
 
 		// pre handler
 		if m.Engine != nil {
-			for _, handler := range m.Engine.preHandler {
+			for i, handler := range m.Engine.preHandler {
+				start := time.Now()
 				c := gorule(handler)
 				for {
 					select {
 					case ok := <-c:
+						mt.step("pre", i, handler, ok, false, start)
 						if !ok { // 有 pre handler 未满足
+							mt.finish(m, false)
 							if m.Break { // 阻断后续
 								break loop
 							}
@@ -296,6 +321,8 @@ loop:
 							t.Reset(maxwait)
 							continue
 						}
+						mt.step("pre", i, handler, false, true, start)
+						observeTimeout("preHandler", m)
 						log.Warnln("[bot] preHandler 处理达到最大时延, 退出")
 						break loop
 					}
@@ -304,12 +331,15 @@ loop:
 			}
 		}
 
-		for _, rule := range m.Rules {
+		for i, rule := range m.Rules {
+			start := time.Now()
 			c := gorule(rule)
 			for {
 				select {
 				case ok := <-c:
+					mt.step("rule", i, rule, ok, false, start)
 					if !ok { // 有 Rule 的条件未满足
+						mt.finish(m, false)
 						if m.Break { // 阻断后续
 							break loop
 						}
@@ -320,6 +350,8 @@ loop:
 						t.Reset(maxwait)
 						continue
 					}
+					mt.step("rule", i, rule, false, true, start)
+					observeTimeout("rule", m)
 					log.Warnln("[bot] rule 处理达到最大时延, 退出")
 					break loop
 				}
@@ -329,12 +361,15 @@ loop:
 
 		// mid handler
 		if m.Engine != nil {
-			for _, handler := range m.Engine.midHandler {
+			for i, handler := range m.Engine.midHandler {
+				start := time.Now()
 				c := gorule(handler)
 				for {
 					select {
 					case ok := <-c:
+						mt.step("mid", i, handler, ok, false, start)
 						if !ok { // 有 mid handler 未满足
+							mt.finish(m, false)
 							if m.Break { // 阻断后续
 								break loop
 							}
@@ -345,6 +380,8 @@ loop:
 							t.Reset(maxwait)
 							continue
 						}
+						mt.step("mid", i, handler, false, true, start)
+						observeTimeout("midHandler", m)
 						log.Warnln("[bot] midHandler 处理达到最大时延, 退出")
 						break loop
 					}
@@ -353,22 +390,29 @@ loop:
 			}
 		}
 
+		consumed = true
 		if m.Handler != nil {
+			observeTriggered(m)
+			start := time.Now()
 			c := gohandler(m.Handler)
 			for {
 				select {
 				case <-c: // 处理事件
+					observeHandler(m, start)
 				case <-t.C:
 					if m.NoTimeout { // 不设超时限制
 						t.Reset(maxwait)
 						continue
 					}
+					mt.finish(m, true)
+					observeTimeout("Handler", m)
 					log.Warnln("[bot] Handler 处理达到最大时延, 退出")
 					break loop
 				}
 				break
 			}
 		}
+		mt.finish(m, true)
 		if matcher.Temp { // 临时 Matcher 删除
 			matcher.Delete()
 		}
@@ -385,6 +429,7 @@ loop:
 							t.Reset(maxwait)
 							continue
 						}
+						observeTimeout("postHandler", m)
 						log.Warnln("[bot] postHandler 处理达到最大时延, 退出")
 						break loop
 					}
@@ -397,6 +442,9 @@ loop:
 			break loop
 		}
 	}
+	if !consumed && (ctx.ma == nil || !ctx.ma.Break) {
+		fuzzyMatch(ctx, matchers, maxwait)
+	}
 }
 
 // preprocessMessageEvent 返回信息事件
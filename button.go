@@ -0,0 +1,45 @@
+package zero
+
+// ButtonClick 按钮回调事件的内容
+type ButtonClick struct {
+	ButtonID  string // 按钮 ID
+	Data      string // 按钮的回调数据
+	MessageID string // 按钮所在消息的 ID
+	UserID    int64  // 点击者
+	GroupID   int64  // 私聊时为 0
+}
+
+// ButtonClick 获取按钮回调事件的内容, 不是按钮回调事件时返回 false
+//
+// 按钮回调事件为 notice_type 为 button_click 的 notice 事件
+func (ctx *Ctx) ButtonClick() (ButtonClick, bool) {
+	e := ctx.Event
+	if e.PostType != "notice" || e.DetailType != "button_click" {
+		return ButtonClick{}, false
+	}
+	return ButtonClick{
+		ButtonID:  e.RawEvent.Get("button_id").String(),
+		Data:      e.RawEvent.Get("button_data").String(),
+		MessageID: e.RawEvent.Get("message_id").String(),
+		UserID:    e.UserID,
+		GroupID:   e.GroupID,
+	}, true
+}
+
+// ButtonRule 匹配按钮回调事件, ids 不为空时只匹配其中的按钮
+//
+// 匹配成功后 State["button_id"] 与 State["button_data"] 为按钮的 ID 与回调数据
+func ButtonRule(ids ...string) Rule {
+	return func(ctx *Ctx) bool {
+		click, ok := ctx.ButtonClick()
+		if !ok {
+			return false
+		}
+		if len(ids) > 0 && !contains(ids, click.ButtonID) {
+			return false
+		}
+		ctx.State["button_id"] = click.ButtonID
+		ctx.State["button_data"] = click.Data
+		return true
+	}
+}
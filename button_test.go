@@ -0,0 +1,35 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestButtonRule(t *testing.T) {
+	raw := `{"post_type":"notice","notice_type":"button_click","user_id":1,"group_id":2,"button_id":"ok","button_data":"confirm:42","message_id":"99"}`
+	ctx := &Ctx{
+		Event: &Event{PostType: "notice", NoticeType: "button_click", DetailType: "button_click", UserID: 1, GroupID: 2, RawEvent: gjson.Parse(raw)},
+		State: State{},
+	}
+	click, ok := ctx.ButtonClick()
+	assert.True(t, ok)
+	assert.Equal(t, ButtonClick{ButtonID: "ok", Data: "confirm:42", MessageID: "99", UserID: 1, GroupID: 2}, click)
+
+	assert.True(t, ButtonRule()(ctx))
+	assert.True(t, ButtonRule("cancel", "ok")(ctx))
+	assert.Equal(t, "ok", ctx.State["button_id"])
+	assert.Equal(t, "confirm:42", ctx.State["button_data"])
+	assert.False(t, ButtonRule("cancel")(ctx))
+
+	ctx.Event = &Event{PostType: "notice", DetailType: "group_increase"}
+	_, ok = ctx.ButtonClick()
+	assert.False(t, ok)
+	assert.False(t, ButtonRule()(ctx))
+
+	e := New()
+	defer e.Delete()
+	m := e.OnButton([]string{"ok"})
+	assert.Equal(t, "notice", m.Info().Type)
+}
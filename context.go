@@ -6,6 +6,8 @@ import (
 	"sync"
 	"unsafe"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/wdvxdr1123/ZeroBot/message"
 )
 
@@ -20,6 +22,9 @@ type Ctx struct {
 	// lazy message
 	once    sync.Once
 	message string
+
+	trace *EventTrace
+	fuzzy bool // 由命令纠错重新匹配的事件
 }
 
 // GetMatcher ...
@@ -36,45 +41,26 @@ func ExposeCaller[T any](ctx *Ctx) *T {
 type decoder []dec
 
 type dec struct {
-	index int
-	key   string
+	index     int
+	key       string
+	name      string
+	def       string
+	hasDef    bool
+	omitempty bool
+	validate  []string
 }
 
 // decoder 缓存
 var decoderCache = sync.Map{}
 
-// Parse 将 Ctx.State 映射到结构体
+// Parse 将 Ctx.State 映射到结构体, 支持的 tag 与类型转换见 ParseState
 func (ctx *Ctx) Parse(model interface{}) (err error) {
-	var (
-		rv       = reflect.ValueOf(model).Elem()
-		t        = rv.Type()
-		modelDec decoder
-	)
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("parse state error: %v", r)
 		}
 	}()
-	d, ok := decoderCache.Load(t)
-	if ok {
-		modelDec = d.(decoder)
-	} else {
-		modelDec = decoder{}
-		for i := 0; i < t.NumField(); i++ {
-			t1 := t.Field(i)
-			if key, ok := t1.Tag.Lookup("zero"); ok {
-				modelDec = append(modelDec, dec{
-					index: i,
-					key:   key,
-				})
-			}
-		}
-		decoderCache.Store(t, modelDec)
-	}
-	for _, d := range modelDec { // decoder类型非小内存，无法被编译器优化为快速拷贝
-		rv.Field(d.index).Set(reflect.ValueOf(ctx.State[d.key]))
-	}
-	return nil
+	return parseState(ctx.State, reflect.ValueOf(model).Elem())
 }
 
 // CheckSession 判断会话连续性
@@ -86,7 +72,17 @@ func (ctx *Ctx) CheckSession() Rule {
 }
 
 // Send 快捷发送消息/合并转发
+//
+// 消息超出 Config.MessageLimit 时按 LongReplyPolicy 转为合并转发或拆分发送
 func (ctx *Ctx) Send(msg interface{}) message.ID {
+	if id, ok := ctx.sendLong(msg); ok {
+		return id
+	}
+	return ctx.send(msg)
+}
+
+// send 发送消息, 不处理长消息
+func (ctx *Ctx) send(msg interface{}) message.ID {
 	event := ctx.Event
 	m, ok := msg.(message.Message)
 	if !ok {
@@ -96,6 +92,12 @@ func (ctx *Ctx) Send(msg interface{}) message.ID {
 			m = *p
 		}
 	}
+	if ok && BotConfig.ValidateMessage {
+		if err := m.Validate(); err != nil {
+			log.Warnln("[bot] 消息校验失败, 已取消发送:", err)
+			return message.ID{}
+		}
+	}
 	if ok && len(m) > 0 && m[0].Type == "node" && event.DetailType != "guild" {
 		if event.GroupID != 0 {
 			return message.NewMessageIDFromInteger(ctx.SendGroupForwardMessage(event.GroupID, m).Get("message_id").Int())
@@ -125,9 +127,9 @@ func (ctx *Ctx) Echo(response []byte) {
 	}
 }
 
-// FutureEvent ...
+// FutureEvent 返回所属会话为当前会话的 FutureEvent
 func (ctx *Ctx) FutureEvent(typ string, rule ...Rule) *FutureEvent {
-	return ctx.ma.FutureEvent(typ, rule...)
+	return ctx.ma.FutureEvent(typ, rule...).SetSession(sessionKey(ctx))
 }
 
 // Get ..
@@ -135,7 +137,11 @@ func (ctx *Ctx) Get(prompt string) string {
 	if prompt != "" {
 		ctx.Send(prompt)
 	}
-	return (<-ctx.FutureEvent("message", ctx.CheckSession()).Next()).Event.RawMessage
+	next := <-ctx.FutureEvent("message", ctx.CheckSession()).Next()
+	if next == nil { // 被取消
+		return ""
+	}
+	return next.Event.RawMessage
 }
 
 // ExtractPlainText 提取消息中的纯文本
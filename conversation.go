@@ -0,0 +1,319 @@
+package zero
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+var (
+	// ErrConversationCanceled 用户发送了取消词
+	ErrConversationCanceled = errors.New("zero: conversation canceled")
+	// ErrConversationTimeout 等待回复超时
+	ErrConversationTimeout = errors.New("zero: conversation timeout")
+	// ErrTooManyRetries 无效输入次数过多
+	ErrTooManyRetries = errors.New("zero: too many invalid inputs")
+)
+
+// Validator 校验并规范化用户的输入, 返回的错误信息会发送给用户
+type Validator func(ctx *Ctx, input string) (string, error)
+
+// Step 对话中的一步
+type Step struct {
+	Name           string        // 答案的键
+	Prompt         string        // 提示
+	Validate       Validator     // 可为 nil
+	Timeout        time.Duration // 为 0 时使用 Conversation 的设置
+	TimeoutMessage string        // 为空时使用 Conversation 的设置
+}
+
+// Conversation 多步对话, 依次发送每一步的提示并收集同一会话的回复
+//
+//	c := zero.NewConversation("register").
+//		Step("name", "请输入昵称").
+//		AddStep(&zero.Step{Name: "age", Prompt: "请输入年龄", Validate: zero.ValidateInt(1, 150)})
+//	engine.OnCommand("register").Handle(func(ctx *zero.Ctx) {
+//		answers, err := c.Run(ctx)
+//		...
+//	})
+type Conversation struct {
+	name           string
+	steps          []*Step
+	timeout        time.Duration
+	timeoutMessage string
+	cancelWords    []string
+	cancelMessage  string
+	retries        int
+	persist        bool
+}
+
+// NewConversation 创建名为 name 的对话, 默认:
+//
+//	每步超时 2 分钟, 取消词 "取消", 无效输入最多重试 3 次, 不持久化进度
+func NewConversation(name string) *Conversation {
+	return &Conversation{
+		name:           name,
+		timeout:        2 * time.Minute,
+		timeoutMessage: "等待超时, 已取消",
+		cancelWords:    []string{"取消"},
+		cancelMessage:  "已取消",
+		retries:        3,
+	}
+}
+
+// Step 添加一步
+func (c *Conversation) Step(name, prompt string, validate ...Validator) *Conversation {
+	s := &Step{Name: name, Prompt: prompt}
+	if len(validate) > 0 {
+		s.Validate = validate[0]
+	}
+	return c.AddStep(s)
+}
+
+// AddStep 添加一步
+func (c *Conversation) AddStep(s *Step) *Conversation {
+	c.steps = append(c.steps, s)
+	return c
+}
+
+// SetTimeout 设置每步的默认超时与超时提示
+func (c *Conversation) SetTimeout(d time.Duration, msg string) *Conversation {
+	c.timeout, c.timeoutMessage = d, msg
+	return c
+}
+
+// SetCancelWords 设置取消词与取消时的提示
+func (c *Conversation) SetCancelWords(msg string, words ...string) *Conversation {
+	c.cancelMessage, c.cancelWords = msg, words
+	return c
+}
+
+// SetRetries 设置无效输入的最大重试次数, 0 为不限
+func (c *Conversation) SetRetries(n int) *Conversation {
+	c.retries = n
+	return c
+}
+
+// Persist 将进度保存到 Storage, 重启后在同一会话再次 Run 时从中断处继续
+func (c *Conversation) Persist() *Conversation {
+	c.persist = true
+	return c
+}
+
+// conversationProgress 持久化的进度
+type conversationProgress struct {
+	Step    int               `json:"step"`
+	Answers map[string]string `json:"answers"`
+}
+
+// 存储格式: conv\x00{name}\x00{gid}{uid} -> json
+func (c *Conversation) key(ctx *Ctx) []byte {
+	k := append(append([]byte("conv\x00"), c.name...), 0)
+	k = binary.BigEndian.AppendUint64(k, uint64(ctx.Event.GroupID))
+	return binary.BigEndian.AppendUint64(k, uint64(ctx.Event.UserID))
+}
+
+func (c *Conversation) load(ctx *Ctx) conversationProgress {
+	p := conversationProgress{Answers: map[string]string{}}
+	if !c.persist {
+		return p
+	}
+	data, err := getStorage().Get(c.key(ctx))
+	if err != nil || data == nil {
+		return p
+	}
+	if err = json.Unmarshal(data, &p); err != nil || p.Step < 0 || p.Step > len(c.steps) {
+		log.Warnln("[conversation] 载入对话", c.name, "进度时出现错误:", err)
+		return conversationProgress{Answers: map[string]string{}}
+	}
+	if p.Answers == nil {
+		p.Answers = map[string]string{}
+	}
+	return p
+}
+
+func (c *Conversation) save(ctx *Ctx, p *conversationProgress) {
+	if !c.persist {
+		return
+	}
+	data, _ := json.Marshal(p)
+	if err := getStorage().Put(c.key(ctx), data); err != nil {
+		log.Warnln("[conversation] 保存对话", c.name, "进度时出现错误:", err)
+	}
+}
+
+// Clear 清除 ctx 所在会话保存的进度
+func (c *Conversation) Clear(ctx *Ctx) {
+	if !c.persist {
+		return
+	}
+	if err := getStorage().Delete(c.key(ctx)); err != nil {
+		log.Warnln("[conversation] 清除对话", c.name, "进度时出现错误:", err)
+	}
+}
+
+// Run 在 ctx 所在会话中进行对话, 返回以 Step.Name 为键的答案
+//
+// 取消、超时或重试次数过多时清除进度并返回对应的错误
+func (c *Conversation) Run(ctx *Ctx) (map[string]string, error) {
+	p := c.load(ctx)
+	recv, cancel := ctx.FutureEvent("message", ctx.CheckSession()).Repeat()
+	defer cancel()
+	for p.Step < len(c.steps) {
+		step := c.steps[p.Step]
+		answer, err := c.ask(ctx, step, recv)
+		if err != nil {
+			c.Clear(ctx)
+			return nil, err
+		}
+		p.Answers[step.Name] = answer
+		p.Step++
+		c.save(ctx, &p)
+	}
+	c.Clear(ctx)
+	return p.Answers, nil
+}
+
+func (c *Conversation) ask(ctx *Ctx, step *Step, recv <-chan *Ctx) (string, error) {
+	timeout, timeoutMessage := step.Timeout, step.TimeoutMessage
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+	if timeoutMessage == "" {
+		timeoutMessage = c.timeoutMessage
+	}
+	if step.Prompt != "" {
+		ctx.SendChain(message.Text(step.Prompt))
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for tries := 1; ; tries++ {
+		var reply *Ctx
+		select {
+		case reply = <-recv:
+			if reply == nil { // 监听被取消
+				return "", ErrConversationCanceled
+			}
+		case <-t.C:
+			if timeoutMessage != "" {
+				ctx.SendChain(message.Text(timeoutMessage))
+			}
+			return "", ErrConversationTimeout
+		}
+		input := strings.TrimSpace(reply.ExtractPlainText())
+		for _, w := range c.cancelWords {
+			if input == w {
+				if c.cancelMessage != "" {
+					ctx.SendChain(message.Text(c.cancelMessage))
+				}
+				return "", ErrConversationCanceled
+			}
+		}
+		if step.Validate == nil {
+			return input, nil
+		}
+		answer, err := step.Validate(reply, input)
+		if err == nil {
+			return answer, nil
+		}
+		if c.retries > 0 && tries >= c.retries {
+			ctx.SendChain(message.Text(err.Error(), ", 已取消"))
+			return "", ErrTooManyRetries
+		}
+		ctx.SendChain(message.Text(err.Error(), ", 请重新输入"))
+	}
+}
+
+// ValidateInt 校验输入为 [min, max] 内的整数
+func ValidateInt(min, max int64) Validator {
+	return func(_ *Ctx, input string) (string, error) {
+		n, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return "", errors.New("请输入整数")
+		}
+		if n < min || n > max {
+			return "", errors.New("请输入 " + strconv.FormatInt(min, 10) + " 到 " + strconv.FormatInt(max, 10) + " 之间的整数")
+		}
+		return input, nil
+	}
+}
+
+// ValidateRegex 校验输入满足正则 regex, 不满足时提示 hint
+func ValidateRegex(regex, hint string) Validator {
+	re := regexp.MustCompile(regex)
+	return func(_ *Ctx, input string) (string, error) {
+		if !re.MatchString(input) {
+			return "", errors.New(hint)
+		}
+		return input, nil
+	}
+}
+
+// Confirm 发送 prompt 并等待同一会话回复 是/否, timeout 为 0 时使用默认的 2 分钟
+//
+// 用户发送 "取消" 时返回 ErrConversationCanceled, 超时返回 ErrConversationTimeout
+func (ctx *Ctx) Confirm(prompt string, timeout time.Duration) (bool, error) {
+	var yes bool
+	_, err := NewConversation("confirm").AddStep(&Step{
+		Prompt:  prompt + " (是/否)",
+		Timeout: timeout,
+		Validate: func(_ *Ctx, input string) (string, error) {
+			switch strings.ToLower(input) {
+			case "是", "确定", "确认", "好", "y", "yes":
+				yes = true
+			case "否", "不", "n", "no":
+				yes = false
+			default:
+				return "", errors.New("请回复 是 或 否")
+			}
+			return input, nil
+		},
+	}).Run(ctx)
+	return yes, err
+}
+
+// Choose 发送 prompt 与带编号的选项并等待同一会话的回复, 返回所选选项的下标
+//
+// 可回复编号或选项内容, 用户发送 "取消" 时返回 ErrConversationCanceled
+func (ctx *Ctx) Choose(prompt string, options []string) (int, error) {
+	if len(options) == 0 {
+		return -1, errors.New("zero: no options to choose")
+	}
+	sb := strings.Builder{}
+	sb.WriteString(prompt)
+	for i, o := range options {
+		sb.WriteString("\n")
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteString(". ")
+		sb.WriteString(o)
+	}
+	index := -1
+	_, err := NewConversation("choose").AddStep(&Step{
+		Prompt: sb.String(),
+		Validate: func(_ *Ctx, input string) (string, error) {
+			if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(options) {
+				index = n - 1
+				return input, nil
+			}
+			for i, o := range options {
+				if strings.EqualFold(input, o) {
+					index = i
+					return input, nil
+				}
+			}
+			return "", errors.New("请回复 1 到 " + strconv.Itoa(len(options)) + " 之间的编号或选项内容")
+		},
+	}).Run(ctx)
+	if err != nil {
+		return -1, err
+	}
+	return index, nil
+}
@@ -0,0 +1,233 @@
+package zero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// chanCaller 将发送的文本写入 chan
+type chanCaller chan string
+
+func (c chanCaller) CallAPI(req APIRequest) (APIResponse, error) {
+	if m, ok := req.Params["message"].(message.Message); ok {
+		c <- m.ExtractPlainText()
+	}
+	return APIResponse{Data: gjson.Parse(`{"message_id":1}`)}, nil
+}
+
+// session 模拟同一会话中的对话
+type session struct {
+	t      *testing.T
+	sent   chanCaller
+	ctx    *Ctx
+	result chan error
+	before map[*Matcher]struct{} // run 之前已存在的 Matcher
+}
+
+func newSession(t *testing.T) *session {
+	sent := make(chanCaller, 16)
+	return &session{
+		t:    t,
+		sent: sent,
+		ctx: &Ctx{
+			Event:  &Event{PostType: "message", DetailType: "private", UserID: 42},
+			State:  State{},
+			caller: sent,
+			ma:     &Matcher{Engine: defaultEngine},
+		},
+		result: make(chan error, 1),
+	}
+}
+
+// matchers 返回 run 之后新注册的 Matcher
+func (s *session) matchers() []*Matcher {
+	matcherLock.RLock()
+	defer matcherLock.RUnlock()
+	var list []*Matcher
+	for _, m := range matcherList {
+		if _, ok := s.before[m]; !ok {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+// run 在后台执行 f, 结果写入 s.result
+func (s *session) run(f func() error) {
+	matcherLock.RLock()
+	s.before = make(map[*Matcher]struct{}, len(matcherList))
+	for _, m := range matcherList {
+		s.before[m] = struct{}{}
+	}
+	matcherLock.RUnlock()
+	go func() { s.result <- f() }()
+}
+
+// expect 等待机器人发送 text
+func (s *session) expect(text string) {
+	select {
+	case got := <-s.sent:
+		assert.Equal(s.t, text, got)
+	case <-time.After(time.Second):
+		s.t.Fatalf("timeout waiting for %q", text)
+	}
+}
+
+// reply 以同一会话发送 text
+func (s *session) reply(text string) {
+	var list []*Matcher
+	assert.Eventually(s.t, func() bool {
+		list = s.matchers()
+		return len(list) > 0
+	}, time.Second, time.Millisecond)
+	match(&Ctx{
+		Event:  &Event{PostType: "message", DetailType: "private", UserID: 42, Message: message.Message{message.Text(text)}},
+		State:  State{},
+		caller: s.sent,
+	}, list, time.Second)
+}
+
+func TestConversation(t *testing.T) {
+	c := NewConversation("test").
+		Step("name", "请输入昵称").
+		Step("age", "请输入年龄", ValidateInt(1, 150)).
+		SetRetries(2)
+
+	s := newSession(t)
+	var answers map[string]string
+	s.run(func() (err error) {
+		answers, err = c.Run(s.ctx)
+		return
+	})
+	s.expect("请输入昵称")
+	s.reply(" 张三 ")
+	s.expect("请输入年龄")
+	s.reply("abc")
+	s.expect("请输入整数, 请重新输入")
+	s.reply("18")
+	assert.NoError(t, <-s.result)
+	assert.Equal(t, map[string]string{"name": "张三", "age": "18"}, answers)
+
+	s = newSession(t)
+	s.run(func() error {
+		_, err := c.Run(s.ctx)
+		return err
+	})
+	s.expect("请输入昵称")
+	s.reply("取消")
+	s.expect("已取消")
+	assert.ErrorIs(t, <-s.result, ErrConversationCanceled)
+
+	s = newSession(t)
+	s.run(func() error {
+		_, err := c.Run(s.ctx)
+		return err
+	})
+	s.expect("请输入昵称")
+	s.reply("a")
+	s.expect("请输入年龄")
+	s.reply("0")
+	s.expect("请输入 1 到 150 之间的整数, 请重新输入")
+	s.reply("x")
+	s.expect("请输入整数, 已取消")
+	assert.ErrorIs(t, <-s.result, ErrTooManyRetries)
+	assert.Eventually(t, func() bool { return len(s.matchers()) == 0 }, time.Second, time.Millisecond, "temp matchers removed")
+}
+
+func TestConversation_TimeoutAndPersist(t *testing.T) {
+	c := NewConversation("persist").
+		Step("name", "请输入昵称").
+		Step("age", "请输入年龄").
+		SetTimeout(20*time.Millisecond, "超时").
+		Persist()
+
+	s := newSession(t)
+	s.run(func() error {
+		_, err := c.Run(s.ctx)
+		return err
+	})
+	s.expect("请输入昵称")
+	s.expect("超时")
+	assert.ErrorIs(t, <-s.result, ErrConversationTimeout)
+
+	// 模拟重启前已完成第一步
+	c.save(s.ctx, &conversationProgress{Step: 1, Answers: map[string]string{"name": "李四"}})
+	c.SetTimeout(time.Second, "超时")
+	var answers map[string]string
+	s.run(func() (err error) {
+		answers, err = c.Run(s.ctx)
+		return
+	})
+	s.expect("请输入年龄")
+	s.reply("20")
+	assert.NoError(t, <-s.result)
+	assert.Equal(t, map[string]string{"name": "李四", "age": "20"}, answers)
+	_, err := getStorage().Get(c.key(s.ctx))
+	assert.Error(t, err, "progress cleared")
+	assert.Eventually(t, func() bool { return len(s.matchers()) == 0 }, time.Second, time.Millisecond, "temp matchers removed")
+}
+
+func TestConfirmAndChoose(t *testing.T) {
+	s := newSession(t)
+	var ok bool
+	s.run(func() (err error) {
+		ok, err = s.ctx.Confirm("确定要踢出吗?", time.Second)
+		return
+	})
+	s.expect("确定要踢出吗? (是/否)")
+	s.reply("嗯")
+	s.expect("请回复 是 或 否, 请重新输入")
+	s.reply("Y")
+	assert.NoError(t, <-s.result)
+	assert.True(t, ok)
+
+	s = newSession(t)
+	s.run(func() error {
+		_, err := s.ctx.Confirm("确定要踢出吗?", 20*time.Millisecond)
+		return err
+	})
+	s.expect("确定要踢出吗? (是/否)")
+	s.expect("等待超时, 已取消")
+	assert.ErrorIs(t, <-s.result, ErrConversationTimeout)
+
+	options := []string{"苹果", "香蕉", "Orange"}
+	s = newSession(t)
+	var index int
+	s.run(func() (err error) {
+		index, err = s.ctx.Choose("请选择水果", options)
+		return
+	})
+	s.expect("请选择水果\n1. 苹果\n2. 香蕉\n3. Orange")
+	s.reply("4")
+	s.expect("请回复 1 到 3 之间的编号或选项内容, 请重新输入")
+	s.reply("2")
+	assert.NoError(t, <-s.result)
+	assert.Equal(t, 1, index)
+
+	s = newSession(t)
+	s.run(func() (err error) {
+		index, err = s.ctx.Choose("请选择水果", options)
+		return
+	})
+	s.expect("请选择水果\n1. 苹果\n2. 香蕉\n3. Orange")
+	s.reply("orange")
+	assert.NoError(t, <-s.result)
+	assert.Equal(t, 2, index)
+
+	s = newSession(t)
+	s.run(func() (err error) {
+		index, err = s.ctx.Choose("请选择水果", options)
+		return
+	})
+	s.expect("请选择水果\n1. 苹果\n2. 香蕉\n3. Orange")
+	s.reply("取消")
+	s.expect("已取消")
+	assert.ErrorIs(t, <-s.result, ErrConversationCanceled)
+	assert.Equal(t, -1, index)
+	assert.Eventually(t, func() bool { return len(s.matchers()) == 0 }, time.Second, time.Millisecond, "temp matchers removed")
+}
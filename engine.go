@@ -1,5 +1,7 @@
 package zero
 
+import "reflect"
+
 // New 生成空引擎
 func New() *Engine {
 	return &Engine{
@@ -18,6 +20,8 @@ type Engine struct {
 	postHandler []Handler
 	block       bool
 	matchers    []*Matcher
+	name        string
+	service     *Service
 }
 
 // Delete 移除该 Engine 注册的所有 Matchers
@@ -36,6 +40,17 @@ func (e *Engine) SetBlock(block bool) *Engine {
 	return e
 }
 
+// SetName 设置 Engine 名称, 用于指标统计等
+func (e *Engine) SetName(name string) *Engine {
+	e.name = name
+	return e
+}
+
+// Name 返回 Engine 名称
+func (e *Engine) Name() string {
+	return e.name
+}
+
 // UsePreHandler 向该 Engine 添加新 PreHandler(Rule),
 // 会在 Rule 判断前触发，如果 preHandler
 // 没有通过，则 Rule, Matcher 不会触发
@@ -72,6 +87,7 @@ func (e *Engine) On(typ string, rules ...Rule) *Matcher {
 		Type:   Type(typ),
 		Rules:  rules,
 		Engine: e,
+		typ:    typ,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -112,6 +128,7 @@ func (e *Engine) OnPrefix(prefix string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{PrefixRule(prefix)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -126,6 +143,7 @@ func (e *Engine) OnSuffix(suffix string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{SuffixRule(suffix)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -139,9 +157,11 @@ func OnCommand(commands string, rules ...Rule) *Matcher {
 // OnCommand 命令触发器
 func (e *Engine) OnCommand(commands string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{CommandRule(commands)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{CommandRule(commands)}, rules...),
+		Engine:   e,
+		typ:      "message",
+		commands: []string{commands},
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -158,6 +178,41 @@ func (e *Engine) OnRegex(regexPattern string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{RegexRule(regexPattern)}, rules...),
 		Engine: e,
+		typ:    "message",
+	}
+	e.matchers = append(e.matchers, matcher)
+	return StoreMatcher(matcher)
+}
+
+// OnPlainRegex 纯文本正则触发器
+func OnPlainRegex(regexPattern string, rules ...Rule) *Matcher {
+	return defaultEngine.OnPlainRegex(regexPattern, rules...)
+}
+
+// OnPlainRegex 纯文本正则触发器
+func (e *Engine) OnPlainRegex(regexPattern string, rules ...Rule) *Matcher {
+	matcher := &Matcher{
+		Type:   Type("message"),
+		Rules:  append([]Rule{PlainRegexRule(regexPattern)}, rules...),
+		Engine: e,
+		typ:    "message",
+	}
+	e.matchers = append(e.matchers, matcher)
+	return StoreMatcher(matcher)
+}
+
+// OnButton 按钮回调触发器, ids 为空时匹配所有按钮
+func OnButton(ids []string, rules ...Rule) *Matcher {
+	return defaultEngine.OnButton(ids, rules...)
+}
+
+// OnButton 按钮回调触发器, ids 为空时匹配所有按钮
+func (e *Engine) OnButton(ids []string, rules ...Rule) *Matcher {
+	matcher := &Matcher{
+		Type:   Type("notice/button_click"),
+		Rules:  append([]Rule{ButtonRule(ids...)}, rules...),
+		Engine: e,
+		typ:    "notice",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -174,6 +229,7 @@ func (e *Engine) OnKeyword(keyword string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{KeywordRule(keyword)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -190,6 +246,7 @@ func (e *Engine) OnFullMatch(src string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{FullMatchRule(src)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -206,6 +263,7 @@ func (e *Engine) OnFullMatchGroup(src []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{FullMatchRule(src...)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -222,6 +280,7 @@ func (e *Engine) OnKeywordGroup(keywords []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{KeywordRule(keywords...)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -234,7 +293,9 @@ func OnCommandGroup(commands []string, rules ...Rule) *Matcher {
 
 // OnCommandGroup 命令触发器组
 func (e *Engine) OnCommandGroup(commands []string, rules ...Rule) *Matcher {
-	return e.On("message", append([]Rule{CommandRule(commands...)}, rules...)...)
+	matcher := e.On("message", append([]Rule{CommandRule(commands...)}, rules...)...)
+	matcher.commands = commands
+	return matcher
 }
 
 // OnPrefixGroup 前缀触发器组
@@ -248,6 +309,7 @@ func (e *Engine) OnPrefixGroup(prefix []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{PrefixRule(prefix...)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -264,6 +326,7 @@ func (e *Engine) OnSuffixGroup(suffix []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{SuffixRule(suffix...)}, rules...),
 		Engine: e,
+		typ:    "message",
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -276,5 +339,21 @@ func OnShell(command string, model interface{}, rules ...Rule) *Matcher {
 
 // OnShell shell命令触发器
 func (e *Engine) OnShell(command string, model interface{}, rules ...Rule) *Matcher {
-	return e.On("message", append([]Rule{ShellRule(command, model)}, rules...)...)
+	matcher := e.On("message", append([]Rule{ShellRule(command, model)}, rules...)...)
+	matcher.commands = []string{command}
+	matcher.shell = reflect.TypeOf(model)
+	return matcher
+}
+
+// OnShellWithUsage shell命令触发器, 参数错误或使用 -h 时回复用法(默认Engine)
+func OnShellWithUsage(command string, model interface{}, rules ...Rule) *Matcher {
+	return defaultEngine.OnShellWithUsage(command, model, rules...)
+}
+
+// OnShellWithUsage shell命令触发器, 参数错误或使用 -h 时回复用法
+func (e *Engine) OnShellWithUsage(command string, model interface{}, rules ...Rule) *Matcher {
+	matcher := e.On("message", append([]Rule{ShellRuleWithUsage(command, model)}, rules...)...)
+	matcher.commands = []string{command}
+	matcher.shell = reflect.TypeOf(model)
+	return matcher
 }
@@ -1,11 +1,23 @@
 package zero
 
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
 // FutureEvent 是 ZeroBot 交互式的核心，用于异步获取指定事件
 type FutureEvent struct {
 	Type     string
 	Priority int
 	Rule     []Rule
 	Block    bool
+
+	// session 创建者所在的会话, 用于限制每个会话等待中的 FutureEvent 数
+	session string
 }
 
 // NewFutureEvent 创建一个FutureEvent, 并返回其指针
@@ -30,57 +42,202 @@ func (m *Matcher) FutureEvent(typ string, rule ...Rule) *FutureEvent {
 	}
 }
 
+// SetSession 设置 FutureEvent 所属的会话,
+// 同一会话等待中的 FutureEvent 数受 Config.MaxPendingFutures 限制
+func (n *FutureEvent) SetSession(session string) *FutureEvent {
+	n.session = session
+	return n
+}
+
+// FutureInfo 是等待中的 FutureEvent 的只读快照
+type FutureInfo struct {
+	Type     string    `json:"type"`
+	Priority int       `json:"priority"`
+	Session  string    `json:"session,omitempty"`
+	Repeat   bool      `json:"repeat"`
+	Since    time.Time `json:"since"`
+	Deadline time.Time `json:"deadline,omitempty"` // 零值表示不超时
+}
+
+// pendingFuture 等待中的 FutureEvent
+type pendingFuture struct {
+	info    FutureInfo
+	matcher *Matcher
+	stop    func() // 取消等待, 可重复调用
+}
+
+var (
+	pendingLock    sync.Mutex
+	pendingFutures []*pendingFuture // 按创建时间排序
+)
+
+// addPending 登记 p, 同一会话超出 Config.MaxPendingFutures 时取消最早的
+func addPending(p *pendingFuture) {
+	var evicted []*pendingFuture
+	pendingLock.Lock()
+	if max := BotConfig.MaxPendingFutures; max > 0 && p.info.Session != "" {
+		n := 0
+		for _, f := range pendingFutures {
+			if f.info.Session == p.info.Session {
+				n++
+			}
+		}
+		for _, f := range pendingFutures {
+			if n < max {
+				break
+			}
+			if f.info.Session == p.info.Session {
+				evicted = append(evicted, f)
+				n--
+			}
+		}
+	}
+	pendingFutures = append(pendingFutures, p)
+	pendingLock.Unlock()
+	for _, f := range evicted {
+		log.Warnln("[future] 会话", f.info.Session, "等待中的 FutureEvent 超出上限, 已取消最早的一个")
+		f.stop()
+	}
+}
+
+func removePending(p *pendingFuture) {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	for i, f := range pendingFutures {
+		if f == p {
+			pendingFutures = append(pendingFutures[:i], pendingFutures[i+1:]...)
+			return
+		}
+	}
+}
+
+// PendingFutures 按创建时间返回所有等待中的 FutureEvent 的快照
+func PendingFutures() []FutureInfo {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	infos := make([]FutureInfo, len(pendingFutures))
+	for i, f := range pendingFutures {
+		infos[i] = f.info
+	}
+	return infos
+}
+
+func (n *FutureEvent) pending(repeat bool) *pendingFuture {
+	return &pendingFuture{
+		info: FutureInfo{
+			Type:     n.Type,
+			Priority: n.Priority,
+			Session:  n.session,
+			Repeat:   repeat,
+			Since:    time.Now(),
+		},
+	}
+}
+
 // Next 返回一个 chan 用于接收下一个指定事件
 //
-// 该 chan 必须接收，如需手动取消监听，请使用 Repeat 方法
+// 该 chan 必须接收，如需手动取消监听，请使用 NextCtx 或 Repeat 方法
 func (n *FutureEvent) Next() <-chan *Ctx {
-	ch := make(chan *Ctx, 1)
-	StoreTempMatcher(&Matcher{
+	return n.next(context.Background(), nil)
+}
+
+// NextWithTimeout 同 Next, 超过 d 未收到事件时删除监听并关闭 chan, 此时接收到 nil
+func (n *FutureEvent) NextWithTimeout(d time.Duration) <-chan *Ctx {
+	c, cancel := context.WithTimeout(context.Background(), d)
+	return n.next(c, cancel)
+}
+
+// NextCtx 同 Next, c 结束时删除监听并关闭 chan, 此时接收到 nil
+func (n *FutureEvent) NextCtx(c context.Context) <-chan *Ctx {
+	return n.next(c, nil)
+}
+
+func (n *FutureEvent) next(c context.Context, release context.CancelFunc) <-chan *Ctx {
+	ch, fin := make(chan *Ctx, 1), make(chan struct{})
+	p := n.pending(false)
+	p.info.Deadline, _ = c.Deadline()
+	var once sync.Once
+	finish := func(ctx *Ctx) {
+		once.Do(func() {
+			p.matcher.Delete()
+			removePending(p)
+			if ctx != nil {
+				ch <- ctx
+			}
+			close(ch)
+			close(fin)
+			if release != nil {
+				release()
+			}
+		})
+	}
+	p.stop = func() { finish(nil) }
+	p.matcher = &Matcher{
 		Type:     Type(n.Type),
 		Block:    n.Block,
 		Priority: n.Priority,
 		Rules:    n.Rule,
 		Engine:   defaultEngine,
-		Handler: func(ctx *Ctx) {
-			ch <- ctx
-			close(ch)
-		},
-	})
+		Handler:  finish,
+	}
+	StoreTempMatcher(p.matcher)
+	addPending(p)
+	if done := c.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				finish(nil)
+			case <-fin:
+			}
+		}()
+	}
 	return ch
 }
 
 // Repeat 返回一个 chan 用于接收无穷个指定事件，和一个取消监听的函数
 //
-// 如果没有取消监听，将不断监听指定事件
+// 如果没有取消监听，将不断监听指定事件; 取消后 chan 会被关闭
 func (n *FutureEvent) Repeat() (recv <-chan *Ctx, cancel func()) {
-	ch, done := make(chan *Ctx, 1), make(chan struct{})
+	ch, in, done := make(chan *Ctx, 1), make(chan *Ctx, 1), make(chan struct{})
+	p := n.pending(true)
+	var once sync.Once
+	p.stop = func() {
+		once.Do(func() {
+			close(done)
+			p.matcher.Delete()
+			removePending(p)
+		})
+	}
+	p.matcher = StoreMatcher(&Matcher{
+		Type:     Type(n.Type),
+		Block:    n.Block,
+		Priority: n.Priority,
+		Rules:    n.Rule,
+		Engine:   defaultEngine,
+		Handler: func(ctx *Ctx) {
+			select {
+			case in <- ctx:
+			case <-done:
+			}
+		},
+	})
+	addPending(p)
 	go func() {
 		defer close(ch)
-		in := make(chan *Ctx, 1)
-		matcher := StoreMatcher(&Matcher{
-			Type:     Type(n.Type),
-			Block:    n.Block,
-			Priority: n.Priority,
-			Rules:    n.Rule,
-			Engine:   defaultEngine,
-			Handler: func(ctx *Ctx) {
-				in <- ctx
-			},
-		})
 		for {
 			select {
 			case e := <-in:
-				ch <- e
+				select {
+				case ch <- e:
+				case <-done:
+					return
+				}
 			case <-done:
-				matcher.Delete()
-				close(in)
 				return
 			}
 		}
 	}()
-	return ch, func() {
-		close(done)
-	}
+	return ch, p.stop
 }
 
 // Take 基于 Repeat 封装，返回一个 chan 接收指定数量的事件
@@ -91,10 +248,19 @@ func (n *FutureEvent) Take(num int) <-chan *Ctx {
 	ch := make(chan *Ctx, num)
 	go func() {
 		defer close(ch)
+		defer cancel()
 		for i := 0; i < num; i++ {
-			ch <- <-recv
+			e, ok := <-recv
+			if !ok {
+				return
+			}
+			ch <- e
 		}
-		cancel()
 	}()
 	return ch
 }
+
+// sessionKey 会话的唯一标识
+func sessionKey(ctx *Ctx) string {
+	return strconv.FormatInt(ctx.Event.GroupID, 10) + ":" + strconv.FormatInt(ctx.Event.UserID, 10)
+}
@@ -0,0 +1,141 @@
+package zero
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pendingMatchers 返回等待中的 FutureEvent 注册的 Matcher
+func pendingMatchers() []*Matcher {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	list := make([]*Matcher, len(pendingFutures))
+	for i, f := range pendingFutures {
+		list[i] = f.matcher
+	}
+	return list
+}
+
+func futureEvent(text string) *Ctx {
+	return &Ctx{
+		Event:  &Event{PostType: "message", DetailType: "private", UserID: 42, RawMessage: text},
+		State:  State{},
+		caller: &recordCaller{},
+	}
+}
+
+func TestFutureEvent_Next(t *testing.T) {
+	next := NewFutureEvent("message", 0, false).SetSession("0:42").Next()
+	infos := PendingFutures()
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, "message", infos[0].Type)
+		assert.Equal(t, "0:42", infos[0].Session)
+		assert.False(t, infos[0].Repeat)
+		assert.True(t, infos[0].Deadline.IsZero())
+	}
+	list := pendingMatchers()
+	match(futureEvent("a"), list, time.Second)
+	match(futureEvent("b"), list, time.Second) // 不会向已关闭的 chan 发送
+	assert.Equal(t, "a", (<-next).Event.RawMessage)
+	assert.Nil(t, <-next)
+	assert.Empty(t, PendingFutures())
+	assert.NotContains(t, matcherList, list[0])
+}
+
+func TestFutureEvent_NextWithTimeout(t *testing.T) {
+	next := NewFutureEvent("message", 0, false).NextWithTimeout(20 * time.Millisecond)
+	infos := PendingFutures()
+	if assert.Len(t, infos, 1) {
+		assert.False(t, infos[0].Deadline.IsZero())
+	}
+	m := pendingMatchers()[0]
+	select {
+	case e := <-next:
+		assert.Nil(t, e)
+	case <-time.After(time.Second):
+		t.Fatal("future not expired")
+	}
+	assert.Empty(t, PendingFutures())
+	assert.NotContains(t, matcherList, m)
+
+	c, cancel := context.WithCancel(context.Background())
+	next = NewFutureEvent("message", 0, false).NextCtx(c)
+	m = pendingMatchers()[0]
+	cancel()
+	assert.Nil(t, <-next)
+	assert.Empty(t, PendingFutures())
+	assert.NotContains(t, matcherList, m)
+}
+
+func TestFutureEvent_Repeat(t *testing.T) {
+	recv, cancel := NewFutureEvent("message", 0, false).Repeat()
+	infos := PendingFutures()
+	if assert.Len(t, infos, 1) {
+		assert.True(t, infos[0].Repeat)
+	}
+	list := pendingMatchers()
+	match(futureEvent("a"), list, time.Second)
+	assert.Equal(t, "a", (<-recv).Event.RawMessage)
+
+	// 无人接收时取消不会阻塞 Handler
+	done := make(chan struct{})
+	go func() {
+		for _, s := range []string{"b", "c", "d"} {
+			match(futureEvent(s), list, time.Second)
+		}
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("handler blocked after cancel")
+	}
+	for range recv { // chan 最终被关闭
+	}
+	assert.Empty(t, PendingFutures())
+	assert.NotContains(t, matcherList, list[0])
+}
+
+func TestFutureEvent_MaxPending(t *testing.T) {
+	BotConfig.MaxPendingFutures = 2
+	defer func() { BotConfig.MaxPendingFutures = 0 }()
+
+	first := NewFutureEvent("message", 0, false).SetSession("1:1").Next()
+	second, _ := NewFutureEvent("message", 0, false).SetSession("1:1").Repeat()
+	other := NewFutureEvent("message", 0, false).SetSession("1:2").Next()
+	third := NewFutureEvent("message", 0, false).SetSession("1:1").Next()
+
+	assert.Nil(t, <-first, "oldest future of the session canceled")
+	infos := PendingFutures()
+	assert.Len(t, infos, 3)
+	select {
+	case <-second:
+		t.Fatal("second future should still be pending")
+	case <-other:
+		t.Fatal("other session should not be affected")
+	case <-third:
+		t.Fatal("third future should still be pending")
+	default:
+	}
+
+	fourth := NewFutureEvent("message", 0, false).SetSession("1:1").NextWithTimeout(time.Minute)
+	_, ok := <-second
+	assert.False(t, ok, "repeat canceled")
+
+	pendingLock.Lock()
+	list := append([]*pendingFuture(nil), pendingFutures...)
+	pendingLock.Unlock()
+	for _, f := range list {
+		f.stop()
+	}
+	assert.Nil(t, <-other)
+	assert.Nil(t, <-third)
+	assert.Nil(t, <-fourth)
+	assert.Empty(t, PendingFutures())
+}
@@ -139,7 +139,7 @@ func unpack(v []byte) map[int64]bool {
 			break
 		}
 		k = binary.LittleEndian.Uint64(b)
-		m[int64(k&0x7fff_ffff_ffff_ffff)] = k&8000_0000_0000_0000 != 0
+		m[int64(k&0x7fff_ffff_ffff_ffff)] = k&0x8000_0000_0000_0000 != 0
 	}
 	return m
 }
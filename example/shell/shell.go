@@ -1,6 +1,8 @@
 package shell
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 
 	zero "github.com/wdvxdr1123/ZeroBot"
@@ -8,17 +10,19 @@ import (
 
 // ShellRule Example
 // 本插件仅作为演示
-// Note: 只有带 flag 的Tag的字段才会注册,
-// 支支持 bool, int, string, float64 四种类型
+// Note: 只有带 flag 或 arg 的Tag的字段才会注册,
+// 支持 bool, 整数, 浮点数, string, time.Duration, 切片,
+// flag.Value 与 message.Segment 等类型
 
 type Ping struct {
-	T       bool   `flag:"t"`
-	Timeout int    `flag:"w"`
-	Host    string `flag:"host"`
+	T       bool          `flag:"t"`
+	Timeout time.Duration `flag:"w,timeout" default:"4s" help:"超时时间"`
+	Proto   string        `flag:"p" enum:"icmp,tcp" default:"icmp" help:"协议"`
+	Host    string        `arg:"0" required:"true" help:"目标主机"`
 }
 
 func init() {
-	zero.OnShell("ping", Ping{}).Handle(func(ctx *zero.Ctx) {
+	zero.OnShellWithUsage("ping", Ping{}).Handle(func(ctx *zero.Ctx) {
 		ping := ctx.State["flag"].(*Ping) // Note: 指针类型
 		logrus.Infoln("ping host:", ping.Host)
 		logrus.Infoln("ping timeout:", ping.Timeout)
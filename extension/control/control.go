@@ -0,0 +1,167 @@
+// Package control 提供服务控制的管理命令
+//
+// 服务状态默认仅保存在内存, 需要持久化时在配置中设置存储:
+//
+//	zero.Run(&zero.Config{Storage: kv.New("zerobot"), ...})
+package control
+
+import (
+	"strconv"
+	"strings"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// Apply 为指定 Engine 注册服务控制命令
+//
+//	启用/enable 服务名          在本群或私聊中启用服务
+//	禁用/disable 服务名         在本群或私聊中禁用服务
+//	封禁/ban 服务名 QQ号或@     在本群封禁用户, 超级用户私聊时为全局封禁
+//	解封/unban 服务名 QQ号或@   解除封禁
+//	服务列表/service_list       查看服务在本群或私聊中的状态
+//	设置前缀/set_prefix 前缀... 设置本群的命令前缀, 不带参数时恢复默认
+//	长消息/long_reply 策略      设置本群长消息的发送方式: 转发/forward 拆分/split 原样/none, 不带参数时恢复默认
+func Apply(engine *zero.Engine) {
+	engine.OnCommandGroup([]string{"启用", "enable"}, zero.UserOrGrpAdmin).
+		SetCategory("服务控制").SetDescription("启用服务").SetUsage("启用 服务名").
+		Handle(func(ctx *zero.Ctx) {
+			s, ok := lookup(ctx)
+			if !ok {
+				return
+			}
+			s.Enable(zero.ServiceTargetID(ctx))
+			ctx.SendChain(message.Text("已启用服务: ", s.Name()))
+		})
+
+	engine.OnCommandGroup([]string{"禁用", "disable"}, zero.UserOrGrpAdmin).
+		SetCategory("服务控制").SetDescription("禁用服务").SetUsage("禁用 服务名").
+		Handle(func(ctx *zero.Ctx) {
+			s, ok := lookup(ctx)
+			if !ok {
+				return
+			}
+			s.Disable(zero.ServiceTargetID(ctx))
+			ctx.SendChain(message.Text("已禁用服务: ", s.Name()))
+		})
+
+	engine.OnCommandGroup([]string{"封禁", "ban"}, zero.AdminPermission).
+		SetCategory("服务控制").SetDescription("封禁用户使用服务").SetUsage("封禁 服务名 QQ号或@").
+		Handle(func(ctx *zero.Ctx) {
+			s, ok := lookup(ctx)
+			if !ok {
+				return
+			}
+			uid := target(ctx)
+			if uid == 0 {
+				ctx.SendChain(message.Text("请指定要封禁的用户"))
+				return
+			}
+			s.Ban(uid, ctx.Event.GroupID)
+			ctx.SendChain(message.Text("已在服务 ", s.Name(), " 中封禁 ", uid))
+		})
+
+	engine.OnCommandGroup([]string{"解封", "unban"}, zero.AdminPermission).
+		SetCategory("服务控制").SetDescription("解除封禁").SetUsage("解封 服务名 QQ号或@").
+		Handle(func(ctx *zero.Ctx) {
+			s, ok := lookup(ctx)
+			if !ok {
+				return
+			}
+			uid := target(ctx)
+			if uid == 0 {
+				ctx.SendChain(message.Text("请指定要解封的用户"))
+				return
+			}
+			s.Unban(uid, ctx.Event.GroupID)
+			ctx.SendChain(message.Text("已在服务 ", s.Name(), " 中解封 ", uid))
+		})
+
+	engine.OnCommandGroup([]string{"服务列表", "service_list"}, zero.UserOrGrpAdmin).
+		SetCategory("服务控制").SetDescription("查看服务状态").
+		Handle(func(ctx *zero.Ctx) {
+			id := zero.ServiceTargetID(ctx)
+			sb := strings.Builder{}
+			sb.WriteString("---服务列表---")
+			i := 0
+			zero.RangeServices(func(name string, s *zero.Service) bool {
+				i++
+				sb.WriteString("\n")
+				sb.WriteString(strconv.Itoa(i))
+				sb.WriteString(": ")
+				if s.IsEnabledIn(id) {
+					sb.WriteString("● ")
+				} else {
+					sb.WriteString("○ ")
+				}
+				sb.WriteString(name)
+				return true
+			})
+			ctx.SendChain(message.Text(sb.String()))
+		})
+
+	engine.OnCommandGroup([]string{"设置前缀", "set_prefix"}, zero.OnlyGroup, zero.AdminPermission).
+		SetCategory("服务控制").SetDescription("设置本群命令前缀").SetUsage("设置前缀 前缀1 前缀2 ...").
+		Handle(func(ctx *zero.Ctx) {
+			args, _ := ctx.State["args"].(string)
+			prefixes := strings.Fields(args)
+			zero.SetGroupCommandPrefixes(ctx.Event.GroupID, prefixes...)
+			if len(prefixes) == 0 {
+				ctx.SendChain(message.Text("已恢复默认命令前缀"))
+				return
+			}
+			ctx.SendChain(message.Text("已设置命令前缀: ", strings.Join(prefixes, " ")))
+		})
+
+	engine.OnCommandGroup([]string{"长消息", "long_reply"}, zero.OnlyGroup, zero.AdminPermission).
+		SetCategory("服务控制").SetDescription("设置本群长消息的发送方式").SetUsage("长消息 转发|拆分|原样").
+		Handle(func(ctx *zero.Ctx) {
+			args, _ := ctx.State["args"].(string)
+			var policy zero.LongReplyPolicy
+			switch strings.TrimSpace(args) {
+			case "":
+				zero.SetGroupLongReplyPolicy(ctx.Event.GroupID, "", true)
+				ctx.SendChain(message.Text("已恢复默认长消息策略"))
+				return
+			case "转发", "forward":
+				policy = zero.LongReplyForward
+			case "拆分", "split":
+				policy = zero.LongReplySplit
+			case "原样", "none":
+				policy = zero.LongReplyNone
+			default:
+				ctx.SendChain(message.Text("未知的策略: ", args, ", 可选: 转发 拆分 原样"))
+				return
+			}
+			zero.SetGroupLongReplyPolicy(ctx.Event.GroupID, policy, false)
+			ctx.SendChain(message.Text("已设置长消息策略: ", strings.TrimSpace(args)))
+		})
+}
+
+// lookup 从参数中获取服务
+func lookup(ctx *zero.Ctx) (*zero.Service, bool) {
+	args, _ := ctx.State["args"].(string)
+	name, _, _ := strings.Cut(args, " ")
+	s, ok := zero.LookupService(name)
+	if !ok {
+		ctx.SendChain(message.Text("没有找到指定服务: ", name))
+	}
+	return s, ok
+}
+
+// target 从 @ 或参数中获取目标用户
+func target(ctx *zero.Ctx) int64 {
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "at" {
+			uid, _ := strconv.ParseInt(seg.Data["qq"], 10, 64)
+			return uid
+		}
+	}
+	args, _ := ctx.State["args"].(string)
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return 0
+	}
+	uid, _ := strconv.ParseInt(fields[1], 10, 64)
+	return uid
+}
@@ -0,0 +1,35 @@
+// Package explain 提供 /explain 命令, 回复一条消息以查看其匹配过程
+//
+// 需要开启 zero.Config.Trace
+package explain
+
+import (
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// Apply 为指定 Engine 注册 explain 命令, 仅超级用户可用
+func Apply(engine *zero.Engine) *zero.Matcher {
+	return engine.OnCommand("explain", zero.SuperUserPermission).Handle(Handle)
+}
+
+// Handle 回复被引用消息的匹配过程
+func Handle(ctx *zero.Ctx) {
+	var id string
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "reply" {
+			id = seg.Data["id"]
+			break
+		}
+	}
+	if id == "" {
+		ctx.SendChain(message.Reply(ctx.Event.MessageID), message.Text("请回复需要解释的消息"))
+		return
+	}
+	tr := zero.GetEventTrace(message.NewMessageIDFromString(id))
+	if tr == nil {
+		ctx.SendChain(message.Reply(ctx.Event.MessageID), message.Text("没有找到该消息的匹配记录, 请确认已开启 Trace 且消息在 5 分钟内"))
+		return
+	}
+	ctx.SendChain(message.Reply(ctx.Event.MessageID), message.Text(tr.String()))
+}
@@ -30,12 +30,14 @@ type FullMatchModel struct {
 	Matched string `zero:"matched"`
 }
 
-// RegexModel is model of zero.RegexRule
+// RegexModel is model of zero.RegexRule and zero.PlainRegexRule
 type RegexModel struct {
-	Matched []string `zero:"regex_matched"`
+	Matched []string          `zero:"regex_matched"`
+	Named   map[string]string `zero:"regex_named,omitempty"`
 }
 
 // PatternModel is model of zero.PatternRule
 type PatternModel struct {
-	Matched []zero.PatternParsed `zero:"pattern_matched"`
+	Matched []zero.PatternParsed          `zero:"pattern_matched"`
+	Named   map[string]zero.PatternParsed `zero:"pattern_named,omitempty"`
 }
@@ -0,0 +1,60 @@
+package extension
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func newCtx(msg ...message.Segment) *zero.Ctx {
+	return &zero.Ctx{Event: &zero.Event{Message: msg}, State: zero.State{}}
+}
+
+func TestRegexModel(t *testing.T) {
+	ctx := newCtx(message.Text("roll 6"))
+	assert.True(t, zero.RegexRule(`^roll (\d+)$`)(ctx))
+	var model RegexModel
+	assert.NoError(t, ctx.Parse(&model))
+	assert.Equal(t, []string{"roll 6", "6"}, model.Matched)
+	assert.Nil(t, model.Named)
+
+	ctx = newCtx(message.Text("roll 6"))
+	assert.True(t, zero.RegexRule(`^roll (?P<n>\d+)$`)(ctx))
+	model = RegexModel{}
+	assert.NoError(t, ctx.Parse(&model))
+	assert.Equal(t, map[string]string{"n": "6"}, model.Named)
+}
+
+func TestPatternModel(t *testing.T) {
+	ctx := newCtx(message.Text("roll 6"))
+	assert.True(t, zero.NewPattern(nil).Text(`roll (\d+)`).AsRule()(ctx))
+	var model PatternModel
+	assert.NoError(t, ctx.Parse(&model))
+	assert.Len(t, model.Matched, 1)
+	assert.Equal(t, []string{"roll 6", "6"}, model.Matched[0].Text())
+	assert.Nil(t, model.Named)
+}
+
+func TestModels(t *testing.T) {
+	tests := []struct {
+		rule     zero.Rule
+		msg      string
+		model    interface{}
+		expected interface{}
+	}{
+		{zero.PrefixRule("echo"), "echo hi", &PrefixModel{}, &PrefixModel{Prefix: "echo", Args: "hi"}},
+		{zero.SuffixRule("吗"), "在吗", &SuffixModel{}, &SuffixModel{Suffix: "吗", Args: "在"}},
+		{zero.CommandRule("ping"), "ping a", &CommandModel{}, &CommandModel{Command: "ping", Args: "a"}},
+		{zero.KeywordRule("bot"), "hi bot", &KeywordModel{}, &KeywordModel{Keyword: "bot"}},
+		{zero.FullMatchRule("hi"), "hi", &FullMatchModel{}, &FullMatchModel{Matched: "hi"}},
+		{zero.PlainRegexRule(`^(\w+)$`), "hi", &RegexModel{}, &RegexModel{Matched: []string{"hi", "hi"}}},
+	}
+	for _, tt := range tests {
+		ctx := newCtx(message.Text(tt.msg))
+		assert.True(t, tt.rule(ctx), tt.msg)
+		assert.NoError(t, ctx.Parse(tt.model), tt.msg)
+		assert.Equal(t, tt.expected, tt.model, tt.msg)
+	}
+}
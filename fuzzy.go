@@ -0,0 +1,190 @@
+package zero
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+	"github.com/wdvxdr1123/ZeroBot/utils/pinyin"
+)
+
+// FuzzyConfig 命令纠错配置
+//
+// 开启后, 带命令前缀的消息未触发任何 Matcher 时,
+// 会与已注册的命令比较相似度并回复建议
+type FuzzyConfig struct {
+	Enable     bool    `json:"enable"`
+	Threshold  float64 `json:"threshold"`   // 给出建议的最低相似度 (默认 0.6)
+	AutoRun    float64 `json:"auto_run"`    // 相似度不低于该值时直接按纠正后的命令执行 (默认关闭)
+	MaxSuggest int     `json:"max_suggest"` // 最多给出的建议数 (默认 3)
+}
+
+// pinyinFunc 将中文转换为拼音, 用于中文命令的相似度比较
+var pinyinFunc = pinyin.Convert
+
+// SetPinyinFunc 设置拼音转换函数, 中文命令会同时比较拼音的相似度,
+// 如 "帮注" 可纠正为 "帮助"
+//
+// 默认使用 utils/pinyin (仅收录 GB2312 汉字), 可替换为 github.com/mozillazg/go-pinyin 等实现,
+// 设为 nil 时只比较字面
+func SetPinyinFunc(f func(string) string) {
+	pinyinFunc = f
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandSimilarity 命令的相似度, 设置了拼音函数时取字面与拼音相似度的较大值
+func commandSimilarity(input, command string) float64 {
+	score := similarity(input, command)
+	if pinyinFunc != nil && (hasHan(input) || hasHan(command)) {
+		if s := similarity(pinyinFunc(input), pinyinFunc(command)); s > score {
+			score = s
+		}
+	}
+	return score
+}
+
+// fuzzyMatch 在没有 Matcher 处理消息时尝试纠正命令
+func fuzzyMatch(ctx *Ctx, matchers []*Matcher, maxwait time.Duration) {
+	cfg := BotConfig.Fuzzy
+	if !cfg.Enable || ctx.fuzzy || ctx.Event.PostType != "message" {
+		return
+	}
+	msg := strings.TrimSpace(ctx.ExtractPlainText())
+	rest, ok := ctx.trimCommandPrefix(msg)
+	if !ok || rest == msg && !ctx.Event.IsToMe {
+		return
+	}
+	prefix := msg[:len(msg)-len(rest)]
+	words := strings.Fields(rest)
+	if len(words) == 0 {
+		return
+	}
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = 0.6
+	}
+	maxSuggest := cfg.MaxSuggest
+	if maxSuggest <= 0 {
+		maxSuggest = 3
+	}
+
+	type candidate struct {
+		command string
+		typed   string // 用户输入中与之比较的部分
+		score   float64
+	}
+	var list []candidate
+	seen := map[string]struct{}{}
+	for _, m := range matchers {
+		info := m.Info()
+		if info.Temp || len(info.Commands) == 0 || !canRun(ctx, m) {
+			continue
+		}
+		for _, c := range info.Commands {
+			n := len(strings.Fields(c))
+			if c == "" || n > len(words) {
+				continue
+			}
+			typed := strings.Join(words[:n], " ")
+			if typed == c {
+				return // 命令存在, 只是条件未满足
+			}
+			if _, ok := seen[c]; ok {
+				continue
+			}
+			seen[c] = struct{}{}
+			if score := commandSimilarity(typed, c); score >= threshold {
+				list = append(list, candidate{command: c, typed: typed, score: score})
+			}
+		}
+	}
+	if len(list) == 0 {
+		return
+	}
+	best := 0
+	for i := range list {
+		if list[i].score > list[best].score {
+			best = i
+		}
+	}
+	if cfg.AutoRun > 0 && list[best].score >= cfg.AutoRun {
+		fuzzyRun(ctx, matchers, maxwait, prefix, list[best].typed, list[best].command)
+		return
+	}
+
+	// 按相似度排序给出建议
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score > list[j].score })
+	if len(list) > maxSuggest {
+		list = list[:maxSuggest]
+	}
+	sb := strings.Builder{}
+	sb.WriteString("未找到命令: ")
+	sb.WriteString(words[0])
+	sb.WriteString(", 你是不是想输入: ")
+	for i, c := range list {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(c.command)
+	}
+	ctx.SendChain(message.Text(sb.String()))
+}
+
+// fuzzyRun 将消息中命令前缀后与 typed 对应的词替换为 command 后重新匹配
+func fuzzyRun(ctx *Ctx, matchers []*Matcher, maxwait time.Duration, prefix, typed, command string) {
+	event := *ctx.Event
+	event.Message = make(message.Message, len(ctx.Event.Message))
+	copy(event.Message, ctx.Event.Message)
+	for i, seg := range event.Message {
+		if seg.Type != "text" || strings.TrimSpace(seg.Data["text"]) == "" {
+			continue
+		}
+		text, ok := replaceCommand(seg.Data["text"], prefix, len(strings.Fields(typed)), command)
+		if !ok {
+			return
+		}
+		event.Message[i] = message.Text(text)
+		break
+	}
+	log.Infof("[bot] 命令 %s 已纠正为 %s", typed, command)
+	match(&Ctx{
+		Event:  &event,
+		State:  State{},
+		caller: ctx.caller,
+		trace:  ctx.trace,
+		fuzzy:  true,
+	}, matchers, maxwait)
+}
+
+// replaceCommand 去除 text 开头的 prefix 后, 按位置将前 n 个词替换为 command
+func replaceCommand(text, prefix string, n int, command string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimLeftFunc(text, unicode.IsSpace), prefix)
+	if !ok {
+		return "", false
+	}
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return "", false
+		}
+		if j := strings.IndexFunc(rest, unicode.IsSpace); j >= 0 {
+			rest = rest[j:]
+		} else {
+			rest = ""
+		}
+	}
+	return prefix + command + rest, true
+}
@@ -0,0 +1,77 @@
+package zero
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+	"github.com/wdvxdr1123/ZeroBot/utils/pinyin"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 0, editDistance("help", "help"))
+	assert.Equal(t, 1, editDistance("hlep", "help"))
+	assert.Equal(t, 1, editDistance("帮注", "帮助"))
+	assert.Equal(t, 4, editDistance("", "help"))
+	assert.Equal(t, 0.75, similarity("hlep", "help"))
+	assert.Equal(t, []string{"ban", "bank"}, suggest("bna", []string{"unban", "ban", "bank", "ban"}, 0.5))
+
+	assert.Equal(t, 1.0, commandSimilarity("帮注", "帮助"), "default pinyin")
+	assert.Equal(t, 1.0, commandSimilarity("帮猪", "帮助"), "tones ignored")
+	defer SetPinyinFunc(pinyin.Convert)
+	SetPinyinFunc(nil)
+	assert.Equal(t, 0.5, commandSimilarity("帮注", "帮助"))
+	SetPinyinFunc(func(s string) string {
+		return strings.NewReplacer("帮", "bang", "助", "zhu", "注", "zhu").Replace(s)
+	})
+	assert.Equal(t, 1.0, commandSimilarity("帮注", "帮助"))
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	old := BotConfig
+	defer func() { BotConfig = old }()
+	BotConfig.CommandPrefix = "/"
+	BotConfig.Fuzzy = FuzzyConfig{Enable: true}
+
+	e := New()
+	defer e.Delete()
+	var ran []string
+	help := e.OnCommand("help").Handle(func(ctx *Ctx) {
+		ran = append(ran, ctx.State["args"].(string))
+	})
+	hello := e.OnCommand("hello").Handle(func(*Ctx) {})
+	ping := e.OnCommand("ping").Handle(func(ctx *Ctx) {
+		ran = append(ran, "ping "+ctx.State["args"].(string))
+	})
+	matchers := []*Matcher{help, hello, ping}
+
+	run := func(text string) *recordCaller {
+		rec := &recordCaller{}
+		ctx := &Ctx{
+			Event:  &Event{PostType: "message", DetailType: "private", UserID: 1, Message: message.Message{message.Text(text)}},
+			State:  State{},
+			caller: rec,
+		}
+		match(ctx, matchers, time.Second)
+		return rec
+	}
+
+	assert.Equal(t, "未找到命令: hlep, 你是不是想输入: /help", run("/hlep").sent())
+	assert.Empty(t, run("hlep").requests, "without prefix")
+	assert.Empty(t, run("/xyz").requests, "nothing similar")
+	assert.Empty(t, run("/help").requests)
+	assert.Equal(t, []string{""}, ran)
+
+	BotConfig.Fuzzy.AutoRun = 0.75
+	assert.Empty(t, run("/hlep 2").requests)
+	assert.Equal(t, []string{"", "2"}, ran)
+	assert.Empty(t, run("/ pnig  a").requests)
+	assert.Empty(t, run("/hlep  hlep").requests)
+	assert.Equal(t, []string{"", "2", "ping a", "hlep"}, ran, "rewrite by position")
+
+	BotConfig.Fuzzy.Enable = false
+	assert.Empty(t, run("/hlep").requests)
+}
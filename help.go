@@ -0,0 +1,256 @@
+package zero
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HelpOption 帮助菜单选项
+type HelpOption struct {
+	Title    string // 标题, 默认 "帮助菜单"
+	PageSize int    // 每页条目数, 默认 10
+}
+
+// HelpEntry 帮助菜单中的一条命令
+type HelpEntry struct {
+	Category    string
+	Commands    []string
+	Description string
+	Usage       string
+	Examples    []string
+}
+
+var (
+	// permissionRules 可在生成帮助时安全求值的权限 Rule
+	permissionRules   = map[uintptr]struct{}{}
+	permissionRulesMu sync.RWMutex
+)
+
+func init() {
+	// OnlyToMe、OnlyGroup 等是对消息场景的判断, 与帮助请求所在的场景无关, 不应注册
+	RegisterPermissionRule(
+		SuperUserPermission, AdminPermission, OwnerPermission, UserOrGrpAdmin,
+		CheckUser(), CheckGroup(),
+	)
+}
+
+// RegisterPermissionRule 标记 rules 为无副作用的权限判断,
+// 生成帮助菜单时会对其求值以隐藏用户无权使用的命令
+//
+// 闭包按其构造函数标记, 如 CheckUser() 标记所有 CheckUser(...)
+func RegisterPermissionRule(rules ...Rule) {
+	permissionRulesMu.Lock()
+	defer permissionRulesMu.Unlock()
+	for _, r := range rules {
+		permissionRules[reflect.ValueOf(r).Pointer()] = struct{}{}
+	}
+}
+
+func isPermissionRule(r Rule) bool {
+	permissionRulesMu.RLock()
+	defer permissionRulesMu.RUnlock()
+	_, ok := permissionRules[reflect.ValueOf(r).Pointer()]
+	return ok
+}
+
+// canRun 判断 ctx 的发送者是否满足 m 的权限 Rule
+func canRun(ctx *Ctx, m *Matcher) bool {
+	check := func(rules []Rule) bool {
+		for _, r := range rules {
+			if isPermissionRule(r) && !r(ctx) {
+				return false
+			}
+		}
+		return true
+	}
+	if m.Engine != nil && !check(m.Engine.preHandler) {
+		return false
+	}
+	return check(m.rules())
+}
+
+func helpEntry(m *Matcher, info MatcherInfo, prefix string) HelpEntry {
+	entry := HelpEntry{Commands: info.Commands}
+	if info.Meta != nil {
+		entry.Category = info.Meta.Category
+		entry.Description = info.Meta.Description
+		entry.Usage = info.Meta.Usage
+		entry.Examples = info.Meta.Examples
+	}
+	if entry.Category == "" && m.Engine != nil {
+		entry.Category = m.Engine.name
+	}
+	if entry.Category == "" {
+		entry.Category = "其它"
+	}
+	if m.shell != nil && len(entry.Commands) > 0 {
+		usage := shellUsage(prefix+entry.Commands[0], m.shell)
+		if entry.Usage != "" {
+			usage = entry.Usage + "\n" + usage
+		}
+		entry.Usage = usage
+	}
+	return entry
+}
+
+// HelpEntries 按分类返回 ctx 的发送者有权使用的命令
+//
+// 只有注册了命令或设置了描述的 Matcher 会被列出
+func HelpEntries(ctx *Ctx) []HelpEntry {
+	var (
+		order   []string
+		grouped = map[string][]HelpEntry{}
+	)
+	rangeMatchers(func(m *Matcher) bool {
+		info := m.Info()
+		if info.Temp || len(info.Commands) == 0 && (info.Meta == nil || info.Meta.Description == "") {
+			return true
+		}
+		if ctx != nil && ctx.Event != nil && !canRun(ctx, m) {
+			return true
+		}
+		entry := helpEntry(m, info, ctx.commandPrefix())
+		if _, ok := grouped[entry.Category]; !ok {
+			order = append(order, entry.Category)
+		}
+		grouped[entry.Category] = append(grouped[entry.Category], entry)
+		return true
+	})
+	entries := make([]HelpEntry, 0, 16)
+	for _, c := range order {
+		entries = append(entries, grouped[c]...)
+	}
+	return entries
+}
+
+func (entry *HelpEntry) title(prefix string) string {
+	if len(entry.Commands) == 0 {
+		return entry.Description
+	}
+	sb := strings.Builder{}
+	for i, c := range entry.Commands {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(c)
+	}
+	if entry.Description != "" {
+		sb.WriteString(": ")
+		sb.WriteString(entry.Description)
+	}
+	return sb.String()
+}
+
+// RenderHelp 生成第 page 页 (从 1 开始) 的帮助菜单, 并返回总页数
+func RenderHelp(ctx *Ctx, page int, opt *HelpOption) (string, int) {
+	title, size := "帮助菜单", 10
+	if opt != nil {
+		if opt.Title != "" {
+			title = opt.Title
+		}
+		if opt.PageSize > 0 {
+			size = opt.PageSize
+		}
+	}
+	entries := HelpEntries(ctx)
+	pages := (len(entries) + size - 1) / size
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	if page > pages {
+		page = pages
+	}
+	sb := strings.Builder{}
+	sb.WriteString(title)
+	if pages > 1 {
+		sb.WriteString(" (")
+		sb.WriteString(strconv.Itoa(page))
+		sb.WriteByte('/')
+		sb.WriteString(strconv.Itoa(pages))
+		sb.WriteByte(')')
+	}
+	if len(entries) == 0 {
+		sb.WriteString("\n暂无可用命令")
+		return sb.String(), pages
+	}
+	category := ""
+	end := page * size
+	if end > len(entries) {
+		end = len(entries)
+	}
+	for _, entry := range entries[(page-1)*size : end] {
+		if entry.Category != category {
+			category = entry.Category
+			sb.WriteString("\n【")
+			sb.WriteString(category)
+			sb.WriteString("】")
+		}
+		sb.WriteString("\n")
+		sb.WriteString(entry.title(ctx.commandPrefix()))
+	}
+	return sb.String(), pages
+}
+
+// RenderCommandHelp 生成单条命令的详细用法, 命令不存在或无权使用时返回 false
+func RenderCommandHelp(ctx *Ctx, command string) (string, bool) {
+	if c, ok := trimPrefixes(command, ctx.CommandPrefixes()); ok {
+		command = c
+	}
+	for _, entry := range HelpEntries(ctx) {
+		for _, c := range entry.Commands {
+			if c != command {
+				continue
+			}
+			sb := strings.Builder{}
+			sb.WriteString(entry.title(ctx.commandPrefix()))
+			if entry.Usage != "" {
+				sb.WriteString("\n")
+				sb.WriteString(entry.Usage)
+			}
+			if len(entry.Examples) > 0 {
+				sb.WriteString("\n示例:")
+				for _, ex := range entry.Examples {
+					sb.WriteString("\n  ")
+					sb.WriteString(ex)
+				}
+			}
+			return sb.String(), true
+		}
+	}
+	return "", false
+}
+
+// OnHelp 帮助菜单触发器(默认Engine)
+func OnHelp(commands []string, opt *HelpOption, rules ...Rule) *Matcher {
+	return defaultEngine.OnHelp(commands, opt, rules...)
+}
+
+// OnHelp 帮助菜单触发器
+//
+// 参数为页码时翻页, 为命令名时显示该命令的用法
+func (e *Engine) OnHelp(commands []string, opt *HelpOption, rules ...Rule) *Matcher {
+	return e.OnCommandGroup(commands, rules...).SetDescription("查看帮助").Handle(func(ctx *Ctx) {
+		args, _ := ctx.State["args"].(string)
+		page := 1
+		if args != "" {
+			var err error
+			page, err = strconv.Atoi(args)
+			if err != nil {
+				text, ok := RenderCommandHelp(ctx, args)
+				if !ok {
+					text = "没有找到命令: " + args
+				}
+				ctx.Send(text)
+				return
+			}
+		}
+		text, _ := RenderHelp(ctx, page, opt)
+		ctx.Send(text)
+	})
+}
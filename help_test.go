@@ -0,0 +1,67 @@
+package zero
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelp(t *testing.T) {
+	type ping struct {
+		Timeout int  `flag:"w" help:"超时时间"`
+		T       bool `flag:"t"`
+	}
+	e := New().SetName("help")
+	defer e.Delete()
+	e.OnCommand("echo").SetDescription("复读").SetUsage("echo 内容").AddExamples("echo hi")
+	e.OnShell("ping", ping{}).SetDescription("测试连通").SetCategory("网络")
+	e.OnCommand("kick", AdminPermission).SetDescription("踢人")
+	e.OnCommand("secret", func(ctx *Ctx) bool { panic("must not be evaluated") })
+	e.OnCommand("poke", OnlyToMe).SetDescription("戳一戳")
+	e.OnCommand("dm", OnlyPrivate).SetDescription("私聊")
+
+	member := &Ctx{Event: &Event{
+		PostType: "message", DetailType: "group", GroupID: 1, UserID: 2,
+		Sender: &User{ID: 2, Role: "member"},
+	}}
+	admin := &Ctx{Event: &Event{
+		PostType: "message", DetailType: "group", GroupID: 1, UserID: 3,
+		Sender: &User{ID: 3, Role: "admin"},
+	}}
+
+	filter := func(entries []HelpEntry) (cmds []string) {
+		for _, entry := range entries {
+			if entry.Category == "help" || entry.Category == "网络" {
+				cmds = append(cmds, entry.Commands...)
+			}
+		}
+		return
+	}
+	// member 的帮助请求未 @机器人 且在群聊中, 场景 Rule 不影响列出
+	assert.Equal(t, []string{"echo", "secret", "poke", "dm", "ping"}, filter(HelpEntries(member)))
+	assert.Equal(t, []string{"echo", "kick", "secret", "poke", "dm", "ping"}, filter(HelpEntries(admin)))
+
+	text, ok := RenderCommandHelp(member, "ping")
+	assert.True(t, ok)
+	assert.Equal(t, "ping: 测试连通\n用法: ping [选项]\n  -w int  超时时间\n  -t", text)
+	text, ok = RenderCommandHelp(member, "echo")
+	assert.True(t, ok)
+	assert.Equal(t, "echo: 复读\necho 内容\n示例:\n  echo hi", text)
+	_, ok = RenderCommandHelp(member, "kick")
+	assert.False(t, ok)
+
+	SetGroupCommandPrefixes(9, "!")
+	defer SetGroupCommandPrefixes(9)
+	custom := &Ctx{Event: &Event{
+		PostType: "message", DetailType: "group", GroupID: 9, UserID: 2,
+		Sender: &User{ID: 2, Role: "member"},
+	}}
+	text, ok = RenderCommandHelp(custom, "!ping")
+	assert.True(t, ok)
+	assert.Equal(t, "!ping: 测试连通\n用法: !ping [选项]\n  -w int  超时时间\n  -t", text, "help uses group prefix")
+
+	text, pages := RenderHelp(admin, 2, &HelpOption{PageSize: 1})
+	assert.GreaterOrEqual(t, pages, 4)
+	assert.True(t, strings.HasPrefix(text, "帮助菜单 (2/"))
+}
@@ -0,0 +1,129 @@
+package zero
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// LongReplyPolicy 超出 Config.MessageLimit 的消息的发送方式
+type LongReplyPolicy string
+
+const (
+	// LongReplyNone 原样发送
+	LongReplyNone LongReplyPolicy = ""
+	// LongReplyForward 拆分后以机器人的名义合并转发, 失败时回退为 LongReplySplit
+	LongReplyForward LongReplyPolicy = "forward"
+	// LongReplySplit 拆分为多条依次发送
+	LongReplySplit LongReplyPolicy = "split"
+)
+
+var (
+	// groupLongReply 群长消息策略覆盖
+	groupLongReply   map[int64]LongReplyPolicy
+	groupLongReplyMu sync.RWMutex
+)
+
+var groupLongReplyKey = []byte("longreply\x00")
+
+func init() {
+	loadGroupLongReply()
+	onStorageChange = append(onStorageChange, loadGroupLongReply)
+}
+
+func loadGroupLongReply() {
+	m := map[int64]LongReplyPolicy{}
+	getStorage().Iterator(func(k, v []byte) bool {
+		if len(k) == len(groupLongReplyKey)+8 && bytes.HasPrefix(k, groupLongReplyKey) {
+			m[int64(binary.BigEndian.Uint64(k[len(groupLongReplyKey):]))] = LongReplyPolicy(v)
+		}
+		return true
+	})
+	groupLongReplyMu.Lock()
+	groupLongReply = m
+	groupLongReplyMu.Unlock()
+}
+
+// SetGroupLongReplyPolicy 设置群 groupID 的长消息策略, 覆盖全局配置
+//
+// reset 为 true 时恢复为全局配置
+func SetGroupLongReplyPolicy(groupID int64, policy LongReplyPolicy, reset bool) {
+	k := binary.BigEndian.AppendUint64(append([]byte(nil), groupLongReplyKey...), uint64(groupID))
+	groupLongReplyMu.Lock()
+	defer groupLongReplyMu.Unlock()
+	var err error
+	if reset {
+		delete(groupLongReply, groupID)
+		err = getStorage().Delete(k)
+	} else {
+		groupLongReply[groupID] = policy
+		err = getStorage().Put(k, []byte(policy))
+	}
+	if err != nil {
+		log.Warnln("[bot] 保存群", groupID, "长消息策略时出现错误:", err)
+	}
+}
+
+// GroupLongReplyPolicy 获取群 groupID 的长消息策略覆盖, 未设置时返回 false
+func GroupLongReplyPolicy(groupID int64) (LongReplyPolicy, bool) {
+	groupLongReplyMu.RLock()
+	defer groupLongReplyMu.RUnlock()
+	p, ok := groupLongReply[groupID]
+	return p, ok
+}
+
+// LongReplyPolicy 返回当前会话的长消息策略
+func (ctx *Ctx) LongReplyPolicy() LongReplyPolicy {
+	if ctx.Event.GroupID != 0 {
+		if p, ok := GroupLongReplyPolicy(ctx.Event.GroupID); ok {
+			return p
+		}
+	}
+	return BotConfig.LongReply
+}
+
+// sendLong 按长消息策略发送 msg, 未超出限制或不需处理时返回 false
+func (ctx *Ctx) sendLong(msg interface{}) (message.ID, bool) {
+	policy := ctx.LongReplyPolicy()
+	if policy == LongReplyNone || ctx.Event.DetailType == "guild" {
+		return message.ID{}, false
+	}
+	var m message.Message
+	switch v := msg.(type) {
+	case message.Message:
+		m = v
+	case *message.Message:
+		m = *v
+	case string:
+		m = message.ParseMessageFromString(v)
+	}
+	if len(m) == 0 || m[0].Type == "node" || !BotConfig.MessageLimit.Exceeds(m) {
+		return message.ID{}, false
+	}
+	parts := message.Split(m, BotConfig.MessageLimit)
+	if policy == LongReplyForward {
+		nodes := message.ForwardNodes(botNickname(), ctx.Event.SelfID, parts...)
+		var rsp message.ID
+		if ctx.Event.GroupID != 0 {
+			rsp = message.NewMessageIDFromInteger(ctx.SendGroupForwardMessage(ctx.Event.GroupID, nodes).Get("message_id").Int())
+		} else {
+			rsp = message.NewMessageIDFromInteger(ctx.SendPrivateForwardMessage(ctx.Event.UserID, nodes).Get("message_id").Int())
+		}
+		if rsp.ID() != 0 {
+			return rsp, true
+		}
+		log.Warnln("[bot] 合并转发长消息失败, 改为拆分发送")
+	}
+	var first message.ID
+	for i, part := range parts {
+		id := ctx.send(part)
+		if i == 0 {
+			first = id
+		}
+	}
+	return first, true
+}
@@ -1,6 +1,7 @@
 package zero
 
 import (
+	"reflect"
 	"sort"
 	"sync"
 )
@@ -35,6 +36,15 @@ type Matcher struct {
 	Handler Handler
 	// Engine 注册 Matcher 的 Engine，Engine可为一系列 Matcher 添加通用 Rule 和 其他钩子
 	Engine *Engine
+	// Meta 描述信息，用于生成帮助菜单等
+	Meta *MatcherMeta
+
+	// typ 注册时的事件类型
+	typ string
+	// commands 注册时的命令
+	commands []string
+	// shell OnShell 注册时的 model 类型
+	shell reflect.Type
 }
 
 var (
@@ -138,6 +148,10 @@ func (m *Matcher) copy() *Matcher {
 		Handler:  m.Handler,
 		Temp:     m.Temp,
 		Engine:   m.Engine,
+		Meta:     m.Meta,
+		typ:      m.typ,
+		commands: m.commands,
+		shell:    m.shell,
 	}
 }
 
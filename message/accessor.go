@@ -0,0 +1,349 @@
+package message
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// AtData at 消息段
+type AtData struct {
+	QQ   int64 // @全体成员 时为 0
+	All  bool
+	Name string // 部分实现提供的名称
+}
+
+// ImageData image 消息段
+type ImageData struct {
+	File    string
+	URL     string
+	Summary string
+	Flash   bool // 闪照
+	SubType int  // 0 为普通图片, 1 为表情包
+}
+
+// MediaData record、video 消息段
+type MediaData struct {
+	File string
+	URL  string
+}
+
+// FileData file 消息段
+type FileData struct {
+	File string
+	Name string
+	URL  string
+	Size int64 // 未知时为 -1
+}
+
+// ReplyData reply 消息段
+type ReplyData struct {
+	ID ID
+}
+
+// FaceData face 消息段
+type FaceData struct {
+	ID    int
+	Emoji rune // 对应的 Emoji, 没有时为 0
+}
+
+// PokeData poke 消息段
+type PokeData struct {
+	QQ int64
+}
+
+// NodeData node 消息段
+type NodeData struct {
+	ID      int64 // 引用已有消息时的 ID, 自定义节点时为 0
+	Name    string
+	UserID  int64
+	Content string
+}
+
+// dataInt 解析整数字段, 不存在或无效时返回 def
+func (m Segment) dataInt(key string, def int64) int64 {
+	n, err := strconv.ParseInt(m.Data[key], 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// AsText 获取 text 消息段的文本
+func (m Segment) AsText() (string, bool) {
+	if m.Type != "text" {
+		return "", false
+	}
+	return m.Data["text"], true
+}
+
+// AsAt 获取 at 消息段的内容
+func (m Segment) AsAt() (AtData, bool) {
+	if m.Type != "at" {
+		return AtData{}, false
+	}
+	if m.Data["qq"] == "all" {
+		return AtData{All: true, Name: m.Data["name"]}, true
+	}
+	qq, err := strconv.ParseInt(m.Data["qq"], 10, 64)
+	if err != nil {
+		return AtData{}, false
+	}
+	return AtData{QQ: qq, Name: m.Data["name"]}, true
+}
+
+// AsImage 获取 image 消息段的内容
+func (m Segment) AsImage() (ImageData, bool) {
+	if m.Type != "image" {
+		return ImageData{}, false
+	}
+	return ImageData{
+		File:    m.Data["file"],
+		URL:     m.Data["url"],
+		Summary: m.Data["summary"],
+		Flash:   m.Data["type"] == "flash",
+		SubType: int(m.dataInt("subType", m.dataInt("sub_type", 0))),
+	}, true
+}
+
+// AsRecord 获取 record 消息段的内容
+func (m Segment) AsRecord() (MediaData, bool) {
+	if m.Type != "record" {
+		return MediaData{}, false
+	}
+	return MediaData{File: m.Data["file"], URL: m.Data["url"]}, true
+}
+
+// AsVideo 获取 video 消息段的内容
+func (m Segment) AsVideo() (MediaData, bool) {
+	if m.Type != "video" {
+		return MediaData{}, false
+	}
+	return MediaData{File: m.Data["file"], URL: m.Data["url"]}, true
+}
+
+// AsFile 获取 file 消息段的内容
+func (m Segment) AsFile() (FileData, bool) {
+	if m.Type != "file" {
+		return FileData{}, false
+	}
+	f := FileData{
+		File: m.Data["file"],
+		Name: m.Data["name"],
+		URL:  m.Data["url"],
+		Size: m.dataInt("file_size", m.dataInt("size", -1)),
+	}
+	if f.Name == "" {
+		f.Name = m.Data["file_name"]
+	}
+	return f, true
+}
+
+// AsReply 获取 reply 消息段回复的消息 ID
+func (m Segment) AsReply() (ReplyData, bool) {
+	if m.Type != "reply" || m.Data["id"] == "" {
+		return ReplyData{}, false
+	}
+	return ReplyData{ID: NewMessageIDFromString(m.Data["id"])}, true
+}
+
+// AsFace 获取 face 消息段的表情
+func (m Segment) AsFace() (FaceData, bool) {
+	if m.Type != "face" {
+		return FaceData{}, false
+	}
+	id, err := strconv.Atoi(m.Data["id"])
+	if err != nil {
+		return FaceData{}, false
+	}
+	return FaceData{ID: id, Emoji: Emoji[id]}, true
+}
+
+// AsPoke 获取 poke 消息段的目标
+func (m Segment) AsPoke() (PokeData, bool) {
+	if m.Type != "poke" {
+		return PokeData{}, false
+	}
+	qq, err := strconv.ParseInt(m.Data["qq"], 10, 64)
+	if err != nil {
+		return PokeData{}, false
+	}
+	return PokeData{QQ: qq}, true
+}
+
+// AsForward 获取 forward 消息段的合并转发 ID
+func (m Segment) AsForward() (string, bool) {
+	if m.Type != "forward" {
+		return "", false
+	}
+	return m.Data["id"], true
+}
+
+// AsNode 获取 node 消息段的内容
+func (m Segment) AsNode() (NodeData, bool) {
+	if m.Type != "node" {
+		return NodeData{}, false
+	}
+	n := NodeData{
+		ID:      m.dataInt("id", 0),
+		Name:    m.Data["name"],
+		UserID:  m.dataInt("uin", m.dataInt("user_id", 0)),
+		Content: m.Data["content"],
+	}
+	if n.Name == "" {
+		n.Name = m.Data["nickname"]
+	}
+	return n, true
+}
+
+// AsJSON 获取 json 消息段的内容
+func (m Segment) AsJSON() (string, bool) {
+	if m.Type != "json" {
+		return "", false
+	}
+	return m.Data["data"], true
+}
+
+// AsXML 获取 xml 消息段的内容
+func (m Segment) AsXML() (string, bool) {
+	if m.Type != "xml" {
+		return "", false
+	}
+	return m.Data["data"], true
+}
+
+// LocationData location 消息段
+type LocationData struct {
+	Lat, Lon float64
+	Title    string
+	Content  string
+}
+
+// ShareData share 消息段
+type ShareData struct {
+	URL     string
+	Title   string
+	Content string
+	Image   string
+}
+
+// ContactData contact 消息段
+type ContactData struct {
+	Type string // qq 或 group
+	ID   int64
+}
+
+// MFaceData mface 消息段
+type MFaceData struct {
+	PackageID string
+	EmojiID   string
+	Key       string
+	Summary   string
+	URL       string
+}
+
+// AsMarkdown 获取 markdown 消息段的内容
+func (m Segment) AsMarkdown() (string, bool) {
+	if m.Type != "markdown" {
+		return "", false
+	}
+	return m.Data["content"], true
+}
+
+// AsKeyboard 获取 keyboard 消息段的按钮
+func (m Segment) AsKeyboard() ([][]Button, bool) {
+	if m.Type != "keyboard" {
+		return nil, false
+	}
+	var c keyboardContent
+	if err := json.Unmarshal([]byte(m.Data["content"]), &c); err != nil {
+		return nil, false
+	}
+	rows := make([][]Button, len(c.Rows))
+	for i, row := range c.Rows {
+		rows[i] = make([]Button, len(row.Buttons))
+		for j, b := range row.Buttons {
+			rows[i][j] = Button{
+				ID:           b.ID,
+				Label:        b.RenderData.Label,
+				VisitedLabel: b.RenderData.VisitedLabel,
+				Style:        b.RenderData.Style,
+				Action:       b.Action.Type,
+				Data:         b.Action.Data,
+				Enter:        b.Action.Enter,
+				Reply:        b.Action.Reply,
+			}
+		}
+	}
+	return rows, true
+}
+
+// AsLongMsg 获取 longmsg 消息段的 ID
+func (m Segment) AsLongMsg() (string, bool) {
+	if m.Type != "longmsg" {
+		return "", false
+	}
+	return m.Data["id"], true
+}
+
+// AsContact 获取 contact 消息段的内容
+func (m Segment) AsContact() (ContactData, bool) {
+	if m.Type != "contact" {
+		return ContactData{}, false
+	}
+	id, err := strconv.ParseInt(m.Data["id"], 10, 64)
+	if err != nil {
+		return ContactData{}, false
+	}
+	return ContactData{Type: m.Data["type"], ID: id}, true
+}
+
+// AsLocation 获取 location 消息段的内容
+func (m Segment) AsLocation() (LocationData, bool) {
+	if m.Type != "location" {
+		return LocationData{}, false
+	}
+	lat, err1 := strconv.ParseFloat(m.Data["lat"], 64)
+	lon, err2 := strconv.ParseFloat(m.Data["lon"], 64)
+	if err1 != nil || err2 != nil {
+		return LocationData{}, false
+	}
+	return LocationData{Lat: lat, Lon: lon, Title: m.Data["title"], Content: m.Data["content"]}, true
+}
+
+// AsShare 获取 share 消息段的内容
+func (m Segment) AsShare() (ShareData, bool) {
+	if m.Type != "share" {
+		return ShareData{}, false
+	}
+	return ShareData{URL: m.Data["url"], Title: m.Data["title"], Content: m.Data["content"], Image: m.Data["image"]}, true
+}
+
+// AsDice 获取 dice 消息段的点数, 发送的消息段没有点数时为 0
+func (m Segment) AsDice() (int, bool) {
+	if m.Type != "dice" {
+		return 0, false
+	}
+	return int(m.dataInt("result", 0)), true
+}
+
+// AsRPS 获取 rps 消息段的结果, 发送的消息段没有结果时为 0
+func (m Segment) AsRPS() (int, bool) {
+	if m.Type != "rps" {
+		return 0, false
+	}
+	return int(m.dataInt("result", 0)), true
+}
+
+// AsMFace 获取 mface 消息段的内容
+func (m Segment) AsMFace() (MFaceData, bool) {
+	if m.Type != "mface" {
+		return MFaceData{}, false
+	}
+	return MFaceData{
+		PackageID: m.Data["emoji_package_id"],
+		EmojiID:   m.Data["emoji_id"],
+		Key:       m.Data["key"],
+		Summary:   m.Data["summary"],
+		URL:       m.Data["url"],
+	}, true
+}
@@ -0,0 +1,85 @@
+package message
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessors(t *testing.T) {
+	at, ok := At(123).AsAt()
+	assert.True(t, ok)
+	assert.Equal(t, AtData{QQ: 123}, at)
+	at, ok = AtAll().AsAt()
+	assert.True(t, ok)
+	assert.True(t, at.All)
+	_, ok = Text("x").AsAt()
+	assert.False(t, ok)
+	_, ok = Segment{Type: "at", Data: map[string]string{"qq": "abc"}}.AsAt()
+	assert.False(t, ok)
+
+	img, ok := Image("a.png", "斗图").Add("url", "http://x").Add("subType", 1).AsImage()
+	assert.True(t, ok)
+	assert.Equal(t, ImageData{File: "a.png", URL: "http://x", Summary: "斗图", SubType: 1}, img)
+
+	face, ok := Face(14).AsFace()
+	assert.True(t, ok)
+	assert.Equal(t, FaceData{ID: 14, Emoji: '🙂'}, face)
+
+	reply, ok := Reply(int64(42)).AsReply()
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), reply.ID.ID())
+
+	f, ok := File("x", "a.zip").AsFile()
+	assert.True(t, ok)
+	assert.Equal(t, FileData{File: "x", Name: "a.zip", Size: -1}, f)
+
+	node, ok := CustomNode("bot", 10, "hi").AsNode()
+	assert.True(t, ok)
+	assert.Equal(t, NodeData{Name: "bot", UserID: 10, Content: "hi"}, node)
+
+	poke, ok := Poke(5).AsPoke()
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), poke.QQ)
+
+	text, ok := Text("a", 1).AsText()
+	assert.True(t, ok)
+	assert.Equal(t, "a1", text)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Message{
+		Text("a"), At(1), AtAll(), Face(1), Image("a.png"), Reply(1),
+		Music("163", 1), CustomMusic("u", "a", "t"), CustomNode("n", 1, "c"), Node(1),
+		JSON(`{"a":1}`), Segment{Type: "unknown", Data: map[string]string{}},
+	}.Validate())
+
+	assert.NoError(t, Text("").Validate(), "empty text is valid")
+	assert.Error(t, Segment{Type: "text", Data: map[string]string{}}.Validate())
+
+	err := Message{
+		Text("ok"),
+		Segment{Type: "face", Data: map[string]string{"id": "x"}},
+		Image("").Add("type", "big"),
+		Segment{Type: "music", Data: map[string]string{"type": "custom", "url": "u"}},
+		JSON("{"),
+	}.Validate()
+	assert.Error(t, err)
+	var fe *FieldError
+	assert.True(t, errors.As(err, &fe))
+	assert.Equal(t, "face", fe.Type)
+	for _, s := range []string{
+		`segment 1: message: face.id: invalid value "x"`,
+		`segment 2: message: image.file: is required`,
+		`segment 2: message: image.type: must be one of [flash show], got "big"`,
+		`segment 3: message: music.audio: is required`,
+		`segment 3: message: music.title: is required`,
+		`segment 4: message: json.data: invalid json`,
+	} {
+		assert.Contains(t, err.Error(), s)
+	}
+
+	RegisterSchema("test_segment", Schema{Fields: map[string]Field{"content": {Required: true}}})
+	assert.Error(t, Segment{Type: "test_segment", Data: map[string]string{}}.Validate())
+}
@@ -0,0 +1,103 @@
+package message
+
+import (
+	"fmt"
+)
+
+// Builder 链式构造 Message
+//
+//	msg := message.NewBuilder().Reply(id).At(qq).Linef("你好, %s", name).Image(url).Message()
+type Builder struct {
+	msg Message
+}
+
+// NewBuilder 创建空的 Builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Append 追加消息段
+func (b *Builder) Append(seg ...Segment) *Builder {
+	for _, s := range seg {
+		if s.Type == "text" {
+			b.text(s.Data["text"])
+			continue
+		}
+		b.msg = append(b.msg, s)
+	}
+	return b
+}
+
+// text 追加文本, 与末尾的文本段合并
+func (b *Builder) text(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(b.msg); n > 0 && b.msg[n-1].Type == "text" {
+		b.msg[n-1] = Text(b.msg[n-1].Data["text"], s)
+		return
+	}
+	b.msg = append(b.msg, Text(s))
+}
+
+// Text 追加文本
+func (b *Builder) Text(text ...interface{}) *Builder {
+	b.text(fmt.Sprint(text...))
+	return b
+}
+
+// Textf 追加格式化的文本
+func (b *Builder) Textf(format string, args ...interface{}) *Builder {
+	b.text(fmt.Sprintf(format, args...))
+	return b
+}
+
+// Linef 追加格式化的文本并换行
+func (b *Builder) Linef(format string, args ...interface{}) *Builder {
+	b.text(fmt.Sprintf(format, args...) + "\n")
+	return b
+}
+
+// Newline 换行
+func (b *Builder) Newline() *Builder {
+	b.text("\n")
+	return b
+}
+
+// At 追加 @qq, qq 为 0 时 @全体成员
+func (b *Builder) At(qq int64) *Builder {
+	b.msg = append(b.msg, At(qq))
+	return b
+}
+
+// Image 追加图片
+func (b *Builder) Image(file string, summary ...interface{}) *Builder {
+	b.msg = append(b.msg, Image(file, summary...))
+	return b
+}
+
+// Face 追加QQ表情
+func (b *Builder) Face(id int) *Builder {
+	b.msg = append(b.msg, Face(id))
+	return b
+}
+
+// Reply 设置回复的消息, 回复段总在消息开头
+func (b *Builder) Reply(id interface{}) *Builder {
+	if len(b.msg) > 0 && b.msg[0].Type == "reply" {
+		b.msg[0] = Reply(id)
+		return b
+	}
+	b.msg = append(Message{Reply(id)}, b.msg...)
+	return b
+}
+
+// Len 返回当前的消息段数
+func (b *Builder) Len() int {
+	return len(b.msg)
+}
+
+// Message 返回构造的消息
+func (b *Builder) Message() Message {
+	return append(Message(nil), b.msg...)
+}
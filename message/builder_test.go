@@ -0,0 +1,86 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	m := NewBuilder().
+		Text("你好, ").
+		At(123).
+		Linef(" 今天是第 %d 天", 3).
+		Text("再见").
+		Newline().
+		Face(1).
+		Image("https://example.com/a.png").
+		Reply(42).
+		Message()
+	assert.Equal(t, Message{
+		Reply(42),
+		Text("你好, "),
+		At(123),
+		Text(" 今天是第 3 天\n再见\n"),
+		Face(1),
+		Image("https://example.com/a.png"),
+	}, m)
+
+	b := NewBuilder().Reply(1).Reply(2).Append(Text("a"), Text("b"))
+	assert.Equal(t, Message{Reply(2), Text("ab")}, b.Message())
+	assert.Equal(t, 2, b.Len())
+}
+
+func TestSplit(t *testing.T) {
+	m := Message{Text("abc"), Image("1"), Text("defgh")}
+	assert.Equal(t, []Message{m}, Split(m, SplitOption{}))
+	assert.False(t, SplitOption{MaxLength: 8}.Exceeds(m))
+	assert.True(t, SplitOption{MaxLength: 7}.Exceeds(m))
+	assert.True(t, SplitOption{MaxSegments: 2}.Exceeds(m))
+
+	// 放不下的文本段移到下一条消息
+	assert.Equal(t, []Message{
+		{Text("abc"), Image("1")},
+		{Text("defgh")},
+	}, Split(m, SplitOption{MaxLength: 6}))
+
+	// 按段数拆分, 回复段留在第一条
+	assert.Equal(t, []Message{
+		{Reply(1), Text("abc"), Image("1")},
+		{Text("defgh")},
+	}, Split(append(Message{Reply(1)}, m...), SplitOption{MaxSegments: 3}))
+
+	// 回复段计入段数, 只允许一段时被丢弃
+	assert.Equal(t, []Message{
+		{Reply(1), Text("a")},
+		{Face(1)},
+	}, Split(Message{Reply(1), Text("a"), Face(1)}, SplitOption{MaxSegments: 2}))
+	assert.Equal(t, []Message{
+		{Text("a")},
+		{Face(1)},
+	}, Split(Message{Reply(1), Text("a"), Face(1)}, SplitOption{MaxSegments: 1}))
+
+	// 超长的文本段优先在换行处切开
+	assert.Equal(t, []Message{
+		{Text("第一行")},
+		{Text("第二行很长很长")},
+		{Text("长")},
+	}, Split(Message{Text("第一行\n第二行很长很长长")}, SplitOption{MaxLength: 7}))
+	assert.Equal(t, []Message{
+		{Text("abcd")},
+		{Text("efgh")},
+		{Text("i")},
+	}, Split(Message{Text("abcdefghi")}, SplitOption{MaxLength: 4}))
+
+	for _, part := range Split(Message{Text("0123456789\n0123\n456789"), At(1), Text("01234567890123")}, SplitOption{MaxLength: 5, MaxSegments: 2}) {
+		assert.False(t, SplitOption{MaxLength: 5, MaxSegments: 2}.Exceeds(part), part)
+	}
+}
+
+func TestForwardNodes(t *testing.T) {
+	nodes := ForwardNodes("bot", 10, Message{Text("a")}, Message{Text("b"), Face(1)})
+	assert.Equal(t, Message{
+		CustomNode("bot", 10, "a"),
+		CustomNode("bot", 10, "b[CQ:face,id=1]"),
+	}, nodes)
+}
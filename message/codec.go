@@ -0,0 +1,211 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Encode 将消息编码为 OneBot 11 数组格式的 JSON
+//
+// 编码前按 Validate 校验消息段, 字段按键排序, 不转义 HTML 字符
+func Encode(m Message) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return encodeArray(m, nil)
+}
+
+// Decode 解析数组或 CQ 字符串格式的消息
+//
+// 与 ParseMessage 不同, 输入不是有效的消息时返回错误
+func Decode(data []byte) (Message, error) {
+	if !gjson.ValidBytes(data) {
+		return nil, errors.New("message: invalid json")
+	}
+	x := gjson.ParseBytes(data)
+	switch {
+	case x.Type == gjson.String:
+		return ParseMessageFromString(x.String()), nil
+	case !x.IsArray():
+		return nil, errors.New("message: expect array or string, got " + x.Type.String())
+	}
+	m := Message{}
+	var err error
+	x.ForEach(func(_, v gjson.Result) bool {
+		typ := v.Get("type")
+		if !v.IsObject() || typ.Type != gjson.String {
+			err = errors.New("message: segment " + strconv.Itoa(len(m)) + ": missing type")
+			return false
+		}
+		seg := Segment{Type: typ.String(), Data: map[string]string{}}
+		data := v.Get("data")
+		if data.Exists() && data.Type != gjson.Null && !data.IsObject() {
+			err = errors.New("message: segment " + strconv.Itoa(len(m)) + ": data is not an object")
+			return false
+		}
+		data.ForEach(func(k, v gjson.Result) bool {
+			seg.Data[k.String()] = v.String()
+			return true
+		})
+		m = append(m, seg)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encodeArray 编码为数组格式, numbers 中的字段以数字输出
+func encodeArray(m Message, numbers map[string]bool) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, seg := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"type":`)
+		if err := writeJSONString(&buf, seg.Type); err != nil {
+			return nil, err
+		}
+		buf.WriteString(`,"data":{`)
+		keys := make([]string, 0, len(seg.Data))
+		for k := range seg.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for j, k := range keys {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONString(&buf, k); err != nil {
+				return nil, err
+			}
+			buf.WriteByte(':')
+			v := seg.Data[k]
+			if numbers[k] {
+				if _, err := strconv.ParseFloat(v, 64); err == nil {
+					buf.WriteString(v)
+					continue
+				}
+			}
+			if err := writeJSONString(&buf, v); err != nil {
+				return nil, err
+			}
+		}
+		buf.WriteString("}}")
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode 会追加换行
+	return nil
+}
+
+// v12Mapping OneBot 11 与 12 之间消息段的对应关系
+type v12Mapping struct {
+	v11, v12 string
+	fields   [][2]string // v11 字段, v12 字段
+}
+
+var v12Mappings = []v12Mapping{
+	{"at", "mention", [][2]string{{"qq", "user_id"}}},
+	{"record", "voice", [][2]string{{"file", "file_id"}}},
+	{"image", "image", [][2]string{{"file", "file_id"}}},
+	{"video", "video", [][2]string{{"file", "file_id"}}},
+	{"file", "file", [][2]string{{"file", "file_id"}}},
+	{"reply", "reply", [][2]string{{"id", "message_id"}, {"qq", "user_id"}}},
+	{"location", "location", [][2]string{{"lat", "latitude"}, {"lon", "longitude"}}},
+}
+
+// v12Numbers OneBot 12 中为数字的字段
+var v12Numbers = map[string]bool{"latitude": true, "longitude": true}
+
+// convertSegment 按 mapping 转换消息段, toV12 为转换的方向
+func convertSegment(seg Segment, toV12 bool) Segment {
+	switch {
+	case toV12 && seg.Type == "at" && seg.Data["qq"] == "all":
+		return Segment{Type: "mention_all", Data: map[string]string{}}
+	case !toV12 && seg.Type == "mention_all":
+		return AtAll()
+	}
+	for _, mp := range v12Mappings {
+		from, to := mp.v11, mp.v12
+		if !toV12 {
+			from, to = to, from
+		}
+		if seg.Type != from {
+			continue
+		}
+		data := make(map[string]string, len(seg.Data))
+		for k, v := range seg.Data {
+			data[k] = v
+		}
+		for _, f := range mp.fields {
+			fk, tk := f[0], f[1]
+			if !toV12 {
+				fk, tk = tk, fk
+			}
+			if v, ok := data[fk]; ok {
+				delete(data, fk)
+				data[tk] = v
+			}
+		}
+		return Segment{Type: to, Data: data}
+	}
+	return seg
+}
+
+// ToV12 将 OneBot 11 的消息段转换为 OneBot 12 的名称与字段, 如 at -> mention, record -> voice
+//
+// 没有对应关系的消息段保持不变
+func ToV12(m Message) Message {
+	out := make(Message, len(m))
+	for i, seg := range m {
+		out[i] = convertSegment(seg, true)
+	}
+	return out
+}
+
+// FromV12 将 OneBot 12 的消息段转换为 OneBot 11 的名称与字段, 如 mention -> at, voice -> record
+//
+// 没有对应关系的消息段保持不变
+func FromV12(m Message) Message {
+	out := make(Message, len(m))
+	for i, seg := range m {
+		out[i] = convertSegment(seg, false)
+	}
+	return out
+}
+
+// EncodeV12 将 OneBot 11 消息转换后编码为 OneBot 12 数组格式的 JSON
+func EncodeV12(m Message) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return encodeArray(ToV12(m), v12Numbers)
+}
+
+// DecodeV12 解析 OneBot 12 数组格式的消息并转换为 OneBot 11 消息
+func DecodeV12(data []byte) (Message, error) {
+	if !gjson.ValidBytes(data) || !gjson.ParseBytes(data).IsArray() {
+		return nil, errors.New("message: expect json array")
+	}
+	m, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return FromV12(m), nil
+}
@@ -0,0 +1,126 @@
+package message
+
+import (
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var roundTripMessages = []Message{
+	{},
+	{Text("a<b>&c"), At(123), AtAll(), Face(14)},
+	{Reply(1), Text("[CQ:face,id=1] 转义, &amp;"), Image("https://example.com/a.png?a=1&b=2", "图")},
+	{Record("r.amr"), Video("v.mp4"), File("f", "a,b].zip")},
+	{Location(39.9, 116.4, "北京", "天安门"), JSON(`{"a":"<b>"}`)},
+	{Markdown("# 标题\n内容"), Dice(), RPS()},
+}
+
+func TestEncodeDecode(t *testing.T) {
+	data, err := Encode(Message{Text("a<b>&c"), Image("a.png").Add("cache", 0)})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"type":"text","data":{"text":"a<b>&c"}},{"type":"image","data":{"cache":"0","file":"a.png"}}]`, string(data))
+
+	data, err = Encode(Message{Text("")})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"type":"text","data":{"text":""}}]`, string(data))
+
+	for _, m := range roundTripMessages {
+		data, err := Encode(m)
+		assert.NoError(t, err)
+		got, err := Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, m, got, string(data))
+
+		// CQ 字符串
+		assert.Equal(t, m, ParseMessageFromString(m.String()))
+		str, _ := json.Marshal(m.String())
+		got, err = Decode(str)
+		assert.NoError(t, err)
+		assert.Equal(t, m, got)
+
+		// OneBot 12
+		assert.Equal(t, m, FromV12(ToV12(m)))
+		data, err = EncodeV12(m)
+		assert.NoError(t, err)
+		got, err = DecodeV12(data)
+		assert.NoError(t, err)
+		assert.Equal(t, m, got, string(data))
+	}
+
+	_, err = Encode(Message{Face(-1), {Type: "face", Data: map[string]string{}}})
+	assert.Error(t, err)
+	for _, s := range []string{`{`, `1`, `{"type":"text"}`, `[1]`, `[{"data":{}}]`, `[{"type":"text","data":"x"}]`} {
+		_, err = Decode([]byte(s))
+		assert.Error(t, err, s)
+	}
+	_, err = DecodeV12([]byte(`"[CQ:face,id=1]"`))
+	assert.Error(t, err)
+}
+
+func TestV12(t *testing.T) {
+	m := Message{At(1), AtAll(), Record("a.amr"), Reply(2).Add("qq", 3), Location(1.5, 2, "", "")}
+	assert.Equal(t, Message{
+		{Type: "mention", Data: map[string]string{"user_id": "1"}},
+		{Type: "mention_all", Data: map[string]string{}},
+		{Type: "voice", Data: map[string]string{"file_id": "a.amr"}},
+		{Type: "reply", Data: map[string]string{"message_id": "2", "user_id": "3"}},
+		{Type: "location", Data: map[string]string{"latitude": "1.5", "longitude": "2"}},
+	}, ToV12(m))
+	data, err := EncodeV12(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"type":"mention","data":{"user_id":"1"}},{"type":"mention_all","data":{}},`+
+		`{"type":"voice","data":{"file_id":"a.amr"}},{"type":"reply","data":{"message_id":"2","user_id":"3"}},`+
+		`{"type":"location","data":{"latitude":1.5,"longitude":2}}]`, string(data))
+
+	got, err := DecodeV12([]byte(`[{"type":"mention","data":{"user_id":10001}},{"type":"text","data":{"text":"hi"}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, Message{At(10001), Text("hi")}, got)
+}
+
+// normalize 合并相邻的文本段, 文本段只保留 text 字段
+func normalize(m Message) Message {
+	out := Message{}
+	for _, seg := range m {
+		if seg.Type != "text" {
+			out = append(out, seg)
+			continue
+		}
+		if seg.Data["text"] == "" {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Type == "text" {
+			out[n-1] = Text(out[n-1].Data["text"], seg.Data["text"])
+			continue
+		}
+		out = append(out, Text(seg.Data["text"]))
+	}
+	return out
+}
+
+func FuzzParseMessageFromString(f *testing.F) {
+	for _, m := range roundTripMessages {
+		f.Add(m.String())
+	}
+	f.Add(`[CQ:face,id=123,id=123][]  [CQ:]&#91;&amp;`)
+	f.Add(`[CQ:a,b]c=d][CQ:text]`)
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) { // JSON 只能表示有效的 UTF-8
+			return
+		}
+		m := ParseMessageFromString(s)
+		for _, seg := range m {
+			if seg.Type == "node" { // node 的参数不转义
+				return
+			}
+		}
+		m = normalize(m)
+		assert.Equal(t, m, normalize(ParseMessageFromString(m.String())))
+		data, err := encodeArray(m, nil)
+		assert.NoError(t, err)
+		got, err := Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, m, got)
+	})
+}
@@ -0,0 +1,264 @@
+// Package media 提供图片、语音、视频等媒体消息段的辅助函数:
+// 限制大小与超时的下载、get_image 解析、类型识别、去重哈希与构造发送用的消息段
+//
+//	engine.OnMessage(zero.HasPicture).Handle(func(ctx *zero.Ctx) {
+//		for _, seg := range ctx.Event.Message {
+//			if seg.Type != "image" {
+//				continue
+//			}
+//			data, err := media.FetchSegment(context.Background(), seg, ctx, nil)
+//			...
+//			ctx.SendChain(media.Image.Bytes(data))
+//		}
+//	})
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+var (
+	// ErrTooLarge 内容超出大小限制
+	ErrTooLarge = errors.New("media: content too large")
+	// ErrNoSource 消息段中没有可用的 url 或 file
+	ErrNoSource = errors.New("media: no url or file in segment")
+	// ErrLocalFile 未设置 FetchOption.AllowLocal 时读取本地文件
+	ErrLocalFile = errors.New("media: reading local file is not allowed")
+)
+
+// FetchOption 下载选项, 零值使用默认值
+type FetchOption struct {
+	MaxSize int64         // 最大字节数 (默认 20MB)
+	Timeout time.Duration // 超时 (默认 30s)
+	Client  *http.Client  // (默认 http.DefaultClient)
+	// AllowLocal 允许读取 file:// 本地文件 (默认不允许),
+	// get_image 返回本地路径时需要设置, 仅在 OneBot 实现与机器人位于同一主机时有意义
+	AllowLocal bool
+}
+
+// DefaultMaxSize 默认的最大字节数
+const DefaultMaxSize = 20 << 20
+
+func (o *FetchOption) maxSize() int64 {
+	if o == nil || o.MaxSize <= 0 {
+		return DefaultMaxSize
+	}
+	return o.MaxSize
+}
+
+func (o *FetchOption) timeout() time.Duration {
+	if o == nil || o.Timeout <= 0 {
+		return 30 * time.Second
+	}
+	return o.Timeout
+}
+
+func (o *FetchOption) client() *http.Client {
+	if o == nil || o.Client == nil {
+		return http.DefaultClient
+	}
+	return o.Client
+}
+
+// Fetch 下载 link 的内容, 支持 http(s)://、file:// 与 base64://
+//
+// 超出 opt.MaxSize 时返回 ErrTooLarge, 未设置 opt.AllowLocal 时拒绝 file://, opt 可为 nil
+func Fetch(ctx context.Context, link string, opt *FetchOption) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(link, "base64://"):
+		data, err := base64.StdEncoding.DecodeString(link[len("base64://"):])
+		if err == nil && int64(len(data)) > opt.maxSize() {
+			return nil, ErrTooLarge
+		}
+		return data, err
+	case strings.HasPrefix(link, "file://"):
+		if opt == nil || !opt.AllowLocal {
+			return nil, ErrLocalFile
+		}
+		f, err := os.Open(localPath(link))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadAll(f, opt.maxSize())
+	}
+	ctx, cancel := context.WithTimeout(ctx, opt.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := opt.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("media: fetch " + link + ": " + resp.Status)
+	}
+	if resp.ContentLength > opt.maxSize() {
+		return nil, ErrTooLarge
+	}
+	return ReadAll(resp.Body, opt.maxSize())
+}
+
+// ReadAll 读取 r 的全部内容, 超出 maxSize 时返回 ErrTooLarge
+func ReadAll(r io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// ImageResolver 通过 get_image 解析图片的 file, *zero.Ctx 即满足此接口
+type ImageResolver interface {
+	GetImage(file string) gjson.Result
+}
+
+// Source 返回消息段可下载的地址, 优先使用 url, 其次为 http(s):// 或 base64:// 的 file,
+// 否则通过 r 调用 get_image 解析, r 可为 nil
+//
+// 消息段来自用户, 其中的 file:// 不会被返回; 只有 get_image 返回的本地路径会转为 file://
+func Source(seg message.Segment, r ImageResolver) (string, error) {
+	if u := seg.Data["url"]; u != "" && !strings.HasPrefix(u, "file://") {
+		return u, nil
+	}
+	file := seg.Data["file"]
+	if file == "" || strings.HasPrefix(file, "file://") {
+		return "", ErrNoSource
+	}
+	for _, p := range []string{"http://", "https://", "base64://"} {
+		if strings.HasPrefix(file, p) {
+			return file, nil
+		}
+	}
+	if r == nil || seg.Type != "image" {
+		return "", ErrNoSource
+	}
+	rsp := r.GetImage(file)
+	if u := rsp.Get("url").String(); u != "" {
+		return u, nil
+	}
+	if f := rsp.Get("file").String(); f != "" {
+		if filepath.IsAbs(f) {
+			return "file://" + filepath.ToSlash(f), nil
+		}
+		return f, nil
+	}
+	return "", ErrNoSource
+}
+
+// FetchSegment 下载消息段的内容, 见 Source 与 Fetch
+func FetchSegment(ctx context.Context, seg message.Segment, r ImageResolver, opt *FetchOption) ([]byte, error) {
+	src, err := Source(seg, r)
+	if err != nil {
+		return nil, err
+	}
+	return Fetch(ctx, src, opt)
+}
+
+// Sniff 识别内容的 MIME 类型, 在 http.DetectContentType 的基础上支持 amr 与 silk 语音
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("#!AMR")):
+		return "audio/amr"
+	case bytes.HasPrefix(data, []byte("#!SILK_V3")), bytes.HasPrefix(data, []byte("\x02#!SILK_V3")):
+		return "audio/silk"
+	}
+	return http.DetectContentType(data)
+}
+
+// Hash 返回内容的 md5 十六进制字符串, 与 QQ 图片文件名一致, 可用于去重
+func Hash(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Kind 媒体消息段的类型
+type Kind string
+
+const (
+	// Image 图片
+	Image Kind = "image"
+	// Record 语音
+	Record Kind = "record"
+	// Video 短视频
+	Video Kind = "video"
+)
+
+// Bytes 以 base64:// 构造消息段
+func (k Kind) Bytes(data []byte) message.Segment {
+	return message.Segment{
+		Type: string(k),
+		Data: map[string]string{
+			"file": "base64://" + base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+// Reader 读取 r 的全部内容构造消息段, 超出 maxSize 时返回 ErrTooLarge, maxSize 为 0 时使用 DefaultMaxSize
+func (k Kind) Reader(r io.Reader, maxSize int64) (message.Segment, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	data, err := ReadAll(r, maxSize)
+	if err != nil {
+		return message.Segment{}, err
+	}
+	return k.Bytes(data), nil
+}
+
+// File 以 file:// 构造本地文件的消息段, 文件须存在
+//
+// 实现与 bot 不在同一台机器上时请使用 Bytes
+func (k Kind) File(path string) (message.Segment, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return message.Segment{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return message.Segment{}, err
+	}
+	if info.IsDir() {
+		return message.Segment{}, errors.New("media: " + path + " is a directory")
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") { // Windows
+		abs = "/" + abs
+	}
+	return message.Segment{
+		Type: string(k),
+		Data: map[string]string{
+			"file": "file://" + abs, // 与 go-cqhttp 等实现一致, 路径不转义
+		},
+	}, nil
+}
+
+// localPath 将 file:// 链接转换为本地路径
+func localPath(link string) string {
+	p := strings.TrimPrefix(link, "file://")
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' { // file:///C:/...
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
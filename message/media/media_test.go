@@ -0,0 +1,114 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png":
+			_, _ = w.Write(png)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	data, err := Fetch(ctx, srv.URL+"/a.png", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, png, data)
+	_, err = Fetch(ctx, srv.URL+"/a.png", &FetchOption{MaxSize: 4})
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = Fetch(ctx, srv.URL+"/slow", &FetchOption{Timeout: 20 * time.Millisecond})
+	assert.Error(t, err)
+	_, err = Fetch(ctx, srv.URL+"/404", nil)
+	assert.Error(t, err)
+
+	data, err = Fetch(ctx, "base64://"+base64.StdEncoding.EncodeToString(png), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, png, data)
+
+	path := filepath.Join(t.TempDir(), "a #1.png")
+	assert.NoError(t, os.WriteFile(path, png, 0o644))
+	seg, err := Image.File(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(seg.Data["file"], "file:///"))
+	_, err = Fetch(ctx, seg.Data["file"], nil)
+	assert.ErrorIs(t, err, ErrLocalFile)
+	_, err = Fetch(ctx, "file:///etc/passwd", &FetchOption{})
+	assert.ErrorIs(t, err, ErrLocalFile)
+	data, err = Fetch(ctx, seg.Data["file"], &FetchOption{AllowLocal: true})
+	assert.NoError(t, err)
+	assert.Equal(t, png, data)
+	_, err = Image.File(filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+}
+
+// resolver 模拟 get_image
+type resolver map[string]string
+
+func (r resolver) GetImage(file string) gjson.Result {
+	return gjson.Parse(r[file])
+}
+
+func TestSource(t *testing.T) {
+	r := resolver{"abc.image": `{"file":"/data/cache/abc.png"}`, "url.image": `{"url":"https://example.com/u.png"}`}
+
+	src, err := Source(message.Image("abc.image").Add("url", "https://example.com/a.png"), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/a.png", src)
+	src, err = Source(message.Image("https://example.com/b.png"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/b.png", src)
+	src, err = Source(message.Image("url.image"), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/u.png", src)
+	src, err = Source(message.Image("abc.image"), r)
+	assert.NoError(t, err)
+	if filepath.Separator == '/' {
+		assert.Equal(t, "file:///data/cache/abc.png", src)
+	}
+	_, err = Source(message.Image("abc.image"), nil)
+	assert.ErrorIs(t, err, ErrNoSource)
+	_, err = Source(message.Image("unknown.image"), r)
+	assert.ErrorIs(t, err, ErrNoSource)
+	_, err = Source(message.Image("file:///etc/passwd"), r)
+	assert.ErrorIs(t, err, ErrNoSource)
+	_, err = Source(message.Image("abc.image").Add("url", "file:///etc/passwd"), nil)
+	assert.ErrorIs(t, err, ErrNoSource)
+}
+
+func TestSegments(t *testing.T) {
+	assert.Equal(t, "image/png", Sniff(png))
+	assert.Equal(t, "audio/amr", Sniff([]byte("#!AMR\n")))
+	assert.Equal(t, "audio/silk", Sniff([]byte("\x02#!SILK_V3")))
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", Hash(nil))
+
+	assert.Equal(t, message.ImageBytes(png), Image.Bytes(png))
+	seg, err := Record.Reader(bytes.NewReader(png), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "record", seg.Type)
+	assert.Equal(t, "base64://"+base64.StdEncoding.EncodeToString(png), seg.Data["file"])
+	_, err = Video.Reader(bytes.NewReader(png), 4)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/crc64"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -84,8 +85,8 @@ func (m Segment) CQCode() string {
 	sb := strings.Builder{}
 	sb.WriteString("[CQ:")
 	sb.WriteString(m.Type)
-	for k, v := range m.Data { // 消息参数
-		// sb.WriteString("," + k + "=" + escape(v))
+	for _, k := range m.keys() { // 消息参数
+		v := m.Data[k]
 		sb.WriteByte(',')
 		sb.WriteString(k)
 		sb.WriteByte('=')
@@ -114,13 +115,23 @@ func (m Segment) CQCode() string {
 	return sb.String()
 }
 
+// keys 返回排序后的参数名
+func (m Segment) keys() []string {
+	keys := make([]string, 0, len(m.Data))
+	for k := range m.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // String impls the interface fmt.Stringer
 func (m Segment) String() string {
 	sb := strings.Builder{}
 	sb.WriteString("[CQ:")
 	sb.WriteString(m.Type)
-	for k, v := range m.Data { // 消息参数
-		// sb.WriteString("," + k + "=" + escape(v))
+	for _, k := range m.keys() { // 消息参数
+		v := m.Data[k]
 		sb.WriteByte(',')
 		sb.WriteString(k)
 		sb.WriteByte('=')
@@ -0,0 +1,138 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Renderer 将消息渲染为便于阅读的文本, 用于日志、搜索索引与 AI 提示词等
+//
+// 为 nil 的解析函数使用默认的渲染方式
+type Renderer struct {
+	// UserName 返回 qq 的名称, 用于渲染 at 段, 默认为 QQ 号
+	UserName func(qq int64) string
+	// Reply 返回被回复的消息, 用于引用其内容, 默认只渲染为 [回复]
+	Reply func(id string) Message
+	// Segment 自定义消息段的渲染, 返回 false 时使用默认的渲染方式
+	Segment func(seg Segment) (string, bool)
+}
+
+// Render 以默认方式渲染 m
+//
+//	[CQ:at,qq=123]你好[CQ:face,id=14][CQ:image,file=a.png] -> @123 你好🙂[图片]
+func Render(m Message) string {
+	return (&Renderer{}).Render(m)
+}
+
+// Render 渲染 m
+func (r *Renderer) Render(m Message) string {
+	sb := strings.Builder{}
+	for _, seg := range m {
+		r.write(&sb, seg)
+	}
+	return sb.String()
+}
+
+func (r *Renderer) write(sb *strings.Builder, seg Segment) {
+	if r.Segment != nil {
+		if s, ok := r.Segment(seg); ok {
+			sb.WriteString(s)
+			return
+		}
+	}
+	switch seg.Type {
+	case "text":
+		sb.WriteString(seg.Data["text"])
+	case "at":
+		qq := seg.Data["qq"]
+		if qq == "all" {
+			sb.WriteString("@全体成员 ")
+			return
+		}
+		name := seg.Data["name"]
+		if id, err := strconv.ParseInt(qq, 10, 64); err == nil && r.UserName != nil {
+			if n := r.UserName(id); n != "" {
+				name = n
+			}
+		}
+		if name == "" {
+			name = qq
+		}
+		sb.WriteString("@" + name + " ")
+	case "face":
+		id, err := strconv.Atoi(seg.Data["id"])
+		if e, ok := Emoji[id]; err == nil && ok {
+			sb.WriteRune(e)
+			return
+		}
+		sb.WriteString("[表情]")
+	case "reply":
+		if r.Reply != nil {
+			if quoted := (&Renderer{UserName: r.UserName, Segment: r.Segment}).Render(r.Reply(seg.Data["id"])); quoted != "" {
+				sb.WriteString("「" + strings.ReplaceAll(quoted, "\n", " ") + "」\n")
+				return
+			}
+		}
+		sb.WriteString("[回复]")
+	default:
+		sb.WriteString(segmentPlaceholder(seg))
+	}
+}
+
+// segmentPlaceholder 非文本消息段的占位文本
+func segmentPlaceholder(seg Segment) string {
+	switch seg.Type {
+	case "image":
+		if s := seg.Data["summary"]; s != "" && s != "[图片]" {
+			return "[图片: " + s + "]"
+		}
+		return "[图片]"
+	case "record":
+		return "[语音]"
+	case "video":
+		return "[视频]"
+	case "file":
+		if name := seg.Data["name"]; name != "" {
+			return "[文件: " + name + "]"
+		}
+		return "[文件]"
+	case "forward", "node":
+		return "[聊天记录]"
+	case "json", "xml":
+		return "[卡片]"
+	case "poke":
+		return "[戳一戳]"
+	case "music":
+		return "[音乐]"
+	case "tts":
+		return seg.Data["text"]
+	case "markdown":
+		return seg.Data["content"]
+	case "keyboard":
+		return ""
+	case "longmsg":
+		return "[长消息]"
+	case "contact":
+		return "[名片]"
+	case "location":
+		if t := seg.Data["title"]; t != "" {
+			return "[位置: " + t + "]"
+		}
+		return "[位置]"
+	case "share":
+		return "[链接: " + seg.Data["title"] + "]"
+	case "dice":
+		return "[骰子]"
+	case "rps":
+		return "[猜拳]"
+	case "shake":
+		return "[窗口抖动]"
+	case "mface":
+		if s := seg.Data["summary"]; s != "" {
+			return s
+		}
+		return "[商城表情]"
+	default:
+		return "[" + seg.Type + "]"
+	}
+}
@@ -0,0 +1,41 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	m := Message{
+		Reply(1),
+		At(123),
+		Text("你好"),
+		Face(14),
+		Face(99999),
+		Image("a.png"),
+		Image("b.png", "斗图"),
+		AtAll(),
+		File("x", "a.zip"),
+		Record("r"),
+		JSON("{}"),
+	}
+	assert.Equal(t, "[回复]@123 你好🙂[表情][图片][图片: 斗图]@全体成员 [文件: a.zip][语音][卡片]", Render(m))
+
+	r := &Renderer{
+		UserName: func(qq int64) string {
+			if qq == 123 {
+				return "小明"
+			}
+			return ""
+		},
+		Reply: func(id string) Message {
+			assert.Equal(t, "1", id)
+			return Message{At(456), Text("第一行\n第二行")}
+		},
+		Segment: func(seg Segment) (string, bool) {
+			return "[图]", seg.Type == "image"
+		},
+	}
+	assert.Equal(t, "「@456 第一行 第二行」\n@小明 你好🙂[表情][图][图]@全体成员 [文件: a.zip][语音][卡片]", r.Render(m))
+}
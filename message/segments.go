@@ -0,0 +1,203 @@
+package message
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/wdvxdr1123/ZeroBot/utils/helper"
+)
+
+// Markdown markdown 消息
+// https://napneko.github.io/develop/msg
+func Markdown(content string) Segment {
+	return Segment{
+		Type: "markdown",
+		Data: map[string]string{
+			"content": content,
+		},
+	}
+}
+
+// ButtonAction 按钮被点击时的动作
+type ButtonAction int
+
+const (
+	// ButtonJump 跳转到 Data 中的链接
+	ButtonJump ButtonAction = iota
+	// ButtonCallback 回调, 由 OnButton 接收
+	ButtonCallback
+	// ButtonCommand 将 Data 作为指令发送
+	ButtonCommand
+)
+
+// Button 键盘中的按钮
+type Button struct {
+	ID           string       `json:"id,omitempty"`
+	Label        string       `json:"label"`
+	VisitedLabel string       `json:"visited_label,omitempty"` // 点击后显示的文字
+	Style        int          `json:"style"`                   // 0 灰色线框, 1 蓝色线框
+	Action       ButtonAction `json:"type"`
+	Data         string       `json:"data"`
+	Enter        bool         `json:"enter,omitempty"` // ButtonCommand 时是否直接发送
+	Reply        bool         `json:"reply,omitempty"` // ButtonCommand 时是否带引用回复
+}
+
+// keyboardContent keyboard 消息段的内容
+type keyboardContent struct {
+	Rows []keyboardRow `json:"rows"`
+}
+
+type keyboardRow struct {
+	Buttons []keyboardButton `json:"buttons"`
+}
+
+// keyboardButton QQ 开放平台格式的按钮
+type keyboardButton struct {
+	ID         string `json:"id,omitempty"`
+	RenderData struct {
+		Label        string `json:"label"`
+		VisitedLabel string `json:"visited_label"`
+		Style        int    `json:"style"`
+	} `json:"render_data"`
+	Action struct {
+		Type       ButtonAction `json:"type"`
+		Data       string       `json:"data"`
+		Enter      bool         `json:"enter,omitempty"`
+		Reply      bool         `json:"reply,omitempty"`
+		Permission struct {
+			Type int `json:"type"`
+		} `json:"permission"`
+		UnsupportTips string `json:"unsupport_tips"`
+	} `json:"action"`
+}
+
+// Keyboard 按钮键盘, 每个 row 为一行按钮, 通常跟在 Markdown 之后发送
+//
+// content 以 JSON 字符串保存
+func Keyboard(rows ...[]Button) Segment {
+	var c keyboardContent
+	c.Rows = make([]keyboardRow, len(rows))
+	for i, row := range rows {
+		c.Rows[i].Buttons = make([]keyboardButton, len(row))
+		for j, b := range row {
+			kb := &c.Rows[i].Buttons[j]
+			kb.ID = b.ID
+			if kb.ID == "" {
+				kb.ID = strconv.Itoa(i) + "_" + strconv.Itoa(j)
+			}
+			kb.RenderData.Label = b.Label
+			kb.RenderData.VisitedLabel = b.VisitedLabel
+			if kb.RenderData.VisitedLabel == "" {
+				kb.RenderData.VisitedLabel = b.Label
+			}
+			kb.RenderData.Style = b.Style
+			kb.Action.Type = b.Action
+			kb.Action.Data = b.Data
+			kb.Action.Enter = b.Enter
+			kb.Action.Reply = b.Reply
+			kb.Action.Permission.Type = 2 // 所有人可点击
+			kb.Action.UnsupportTips = "当前版本不支持该按钮"
+		}
+	}
+	data, _ := json.Marshal(&c)
+	return Segment{
+		Type: "keyboard",
+		Data: map[string]string{
+			"content": helper.BytesToString(data),
+		},
+	}
+}
+
+// LongMsg 长消息
+func LongMsg(id string) Segment {
+	return Segment{
+		Type: "longmsg",
+		Data: map[string]string{
+			"id": id,
+		},
+	}
+}
+
+// Contact 推荐好友/群
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E6%8E%A8%E8%8D%90%E5%A5%BD%E5%8F%8B
+//
+// typ 为 qq 或 group
+func Contact(typ string, id int64) Segment {
+	return Segment{
+		Type: "contact",
+		Data: map[string]string{
+			"type": typ,
+			"id":   strconv.FormatInt(id, 10),
+		},
+	}
+}
+
+// Location 位置
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E4%BD%8D%E7%BD%AE
+func Location(lat, lon float64, title, content string) Segment {
+	m := Segment{
+		Type: "location",
+		Data: map[string]string{
+			"lat": strconv.FormatFloat(lat, 'f', -1, 64),
+			"lon": strconv.FormatFloat(lon, 'f', -1, 64),
+		},
+	}
+	if title != "" {
+		m.Data["title"] = title
+	}
+	if content != "" {
+		m.Data["content"] = content
+	}
+	return m
+}
+
+// Share 链接分享
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E9%93%BE%E6%8E%A5%E5%88%86%E4%BA%AB
+func Share(url, title, content, image string) Segment {
+	m := Segment{
+		Type: "share",
+		Data: map[string]string{
+			"url":   url,
+			"title": title,
+		},
+	}
+	if content != "" {
+		m.Data["content"] = content
+	}
+	if image != "" {
+		m.Data["image"] = image
+	}
+	return m
+}
+
+// Dice 掷骰子魔法表情
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E6%8E%B7%E9%AA%B0%E5%AD%90%E9%AD%94%E6%B3%95%E8%A1%A8%E6%83%85
+func Dice() Segment {
+	return Segment{Type: "dice", Data: map[string]string{}}
+}
+
+// RPS 猜拳魔法表情
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E7%8C%9C%E6%8B%B3%E9%AD%94%E6%B3%95%E8%A1%A8%E6%83%85
+func RPS() Segment {
+	return Segment{Type: "rps", Data: map[string]string{}}
+}
+
+// Shake 窗口抖动 (戳一戳)
+// https://github.com/botuniverse/onebot-11/tree/master/message/segment.md#%E7%AA%97%E5%8F%A3%E6%8A%96%E5%8A%A8%E6%88%B3%E4%B8%80%E6%88%B3-
+func Shake() Segment {
+	return Segment{Type: "shake", Data: map[string]string{}}
+}
+
+// MFace 商城表情
+// https://napneko.github.io/develop/msg
+func MFace(packageID, emojiID, key, summary string) Segment {
+	return Segment{
+		Type: "mface",
+		Data: map[string]string{
+			"emoji_package_id": packageID,
+			"emoji_id":         emojiID,
+			"key":              key,
+			"summary":          summary,
+		},
+	}
+}
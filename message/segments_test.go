@@ -0,0 +1,59 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegments(t *testing.T) {
+	kb := Keyboard(
+		[]Button{{Label: "确认", Action: ButtonCallback, Data: "ok"}, {ID: "cancel", Label: "取消", Action: ButtonCallback, Data: "no", Style: 1}},
+		[]Button{{Label: "帮助", Action: ButtonCommand, Data: "/help", Enter: true}},
+	)
+	rows, ok := kb.AsKeyboard()
+	assert.True(t, ok)
+	assert.Equal(t, [][]Button{
+		{{ID: "0_0", Label: "确认", VisitedLabel: "确认", Action: ButtonCallback, Data: "ok"}, {ID: "cancel", Label: "取消", VisitedLabel: "取消", Action: ButtonCallback, Data: "no", Style: 1}},
+		{{ID: "1_0", Label: "帮助", VisitedLabel: "帮助", Action: ButtonCommand, Data: "/help", Enter: true}},
+	}, rows)
+
+	msg := Message{
+		Markdown("# 标题"), kb, LongMsg("abc"), Contact("group", 123),
+		Location(39.9, 116.4, "北京", ""), Share("https://example.com", "示例", "", ""),
+		Dice(), RPS(), Shake(), MFace("1", "2", "k", "[开心]"),
+	}
+	assert.NoError(t, msg.Validate())
+	assert.Equal(t, "# 标题[长消息][名片][位置: 北京][链接: 示例][骰子][猜拳][窗口抖动][开心]", Render(msg))
+
+	md, ok := msg[0].AsMarkdown()
+	assert.True(t, ok)
+	assert.Equal(t, "# 标题", md)
+	id, ok := msg[2].AsLongMsg()
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+	c, ok := msg[3].AsContact()
+	assert.True(t, ok)
+	assert.Equal(t, ContactData{Type: "group", ID: 123}, c)
+	loc, ok := msg[4].AsLocation()
+	assert.True(t, ok)
+	assert.Equal(t, LocationData{Lat: 39.9, Lon: 116.4, Title: "北京"}, loc)
+	share, ok := msg[5].AsShare()
+	assert.True(t, ok)
+	assert.Equal(t, ShareData{URL: "https://example.com", Title: "示例"}, share)
+	mf, ok := msg[9].AsMFace()
+	assert.True(t, ok)
+	assert.Equal(t, MFaceData{PackageID: "1", EmojiID: "2", Key: "k", Summary: "[开心]"}, mf)
+
+	n, ok := Segment{Type: "dice", Data: map[string]string{"result": "6"}}.AsDice()
+	assert.True(t, ok)
+	assert.Equal(t, 6, n)
+	_, ok = Dice().AsRPS()
+	assert.False(t, ok)
+
+	cqMsg := ParseMessageFromString(Message{Contact("qq", 1), Dice()}.String())
+	assert.Equal(t, Message{Contact("qq", 1), Dice()}, cqMsg)
+
+	assert.Error(t, Segment{Type: "keyboard", Data: map[string]string{"content": "x"}}.Validate())
+	assert.Error(t, Contact("friend", 1).Validate())
+}
@@ -0,0 +1,107 @@
+package message
+
+import (
+	"unicode/utf8"
+)
+
+// SplitOption 单条消息的限制, 零值表示不限制
+type SplitOption struct {
+	MaxLength   int `json:"max_length"`   // 纯文本的最大字符数
+	MaxSegments int `json:"max_segments"` // 最大消息段数
+}
+
+// Exceeds 判断 m 是否超出限制
+func (o SplitOption) Exceeds(m Message) bool {
+	if o.MaxSegments > 0 && len(m) > o.MaxSegments {
+		return true
+	}
+	return o.MaxLength > 0 && utf8.RuneCountInString(m.ExtractPlainText()) > o.MaxLength
+}
+
+// Split 将 m 拆分为多条不超出限制的消息
+//
+// 只在消息段之间拆分, 单个文本段超长时优先在换行处切开;
+// 开头的回复段保留在第一条消息中并计入 MaxSegments, MaxSegments 为 1 时无法容纳而被丢弃
+func Split(m Message, opt SplitOption) []Message {
+	var reply Message
+	if len(m) > 0 && m[0].Type == "reply" {
+		reply, m = m[:1], m[1:]
+		if opt.MaxSegments == 1 {
+			reply = nil
+		}
+	}
+	var (
+		parts []Message
+		cur   Message
+		n     int // cur 中的字符数
+	)
+	full := func() bool {
+		return opt.MaxSegments > 0 && len(cur) >= opt.MaxSegments
+	}
+	flush := func() {
+		if len(cur) > 0 {
+			parts = append(parts, cur)
+			cur, n = nil, 0
+		}
+	}
+	if len(reply) > 0 {
+		cur = append(cur, reply...)
+	}
+	for _, seg := range m {
+		if seg.Type != "text" {
+			if full() {
+				flush()
+			}
+			cur = append(cur, seg)
+			continue
+		}
+		text := []rune(seg.Data["text"])
+		for len(text) > 0 {
+			if full() {
+				flush()
+			}
+			room := len(text)
+			if opt.MaxLength > 0 {
+				room = opt.MaxLength - n
+			}
+			if len(text) <= room {
+				cur = append(cur, Text(string(text)))
+				n += len(text)
+				break
+			}
+			if room <= 0 {
+				flush()
+				continue
+			}
+			cut := room
+			for cut >= 0 && text[cut] != '\n' {
+				cut--
+			}
+			switch {
+			case cut >= 0:
+				if cut > 0 {
+					cur = append(cur, Text(string(text[:cut])))
+				}
+				text = text[cut+1:]
+			case n > 0: // 在新的消息中放入
+				flush()
+				continue
+			default:
+				cur = append(cur, Text(string(text[:room])))
+				text = text[room:]
+			}
+			flush()
+		}
+	}
+	flush()
+	return parts
+}
+
+// ForwardNodes 将多条消息打包为合并转发节点
+func ForwardNodes(nickname string, userID int64, msgs ...Message) Message {
+	nodes := make(Message, 0, len(msgs))
+	for _, m := range msgs {
+		nodes = append(nodes, CustomNode(nickname, userID, m))
+	}
+	return nodes
+}
@@ -0,0 +1,247 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// FieldKind 字段值的类型
+type FieldKind uint8
+
+const (
+	// FieldString 任意字符串
+	FieldString FieldKind = iota
+	// FieldInt 整数
+	FieldInt
+	// FieldFloat 浮点数
+	FieldFloat
+	// FieldBool 布尔值, 如 0 1 true false
+	FieldBool
+	// FieldEnum Field.Enum 中的一个
+	FieldEnum
+)
+
+// Field 消息段字段的规范
+type Field struct {
+	Kind       FieldKind
+	Required   bool // 必须存在且非空
+	AllowEmpty bool // 与 Required 同时设置时只要求存在, 允许空值
+	Enum       []string
+}
+
+// Schema 消息段的规范
+type Schema struct {
+	Fields map[string]Field
+	// Check 额外的校验, 可为 nil
+	Check func(seg Segment) error
+}
+
+// FieldError 消息段字段无效
+type FieldError struct {
+	Type   string
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return "message: " + e.Type + ": " + e.Reason
+	}
+	return "message: " + e.Type + "." + e.Field + ": " + e.Reason
+}
+
+var (
+	schemaMu sync.RWMutex
+	// schemas OneBot 11 与 go-cqhttp、LLOneBot 等扩展的消息段规范
+	schemas = map[string]Schema{
+		"text": {Fields: map[string]Field{"text": {Required: true, AllowEmpty: true}}},
+		"face": {Fields: map[string]Field{"id": {Kind: FieldInt, Required: true}}},
+		"image": {Fields: map[string]Field{
+			"file":    {Required: true},
+			"type":    {Kind: FieldEnum, Enum: []string{"flash", "show"}},
+			"subType": {Kind: FieldInt},
+			"cache":   {Kind: FieldBool},
+			"proxy":   {Kind: FieldBool},
+			"timeout": {Kind: FieldInt},
+		}},
+		"record": {Fields: map[string]Field{
+			"file":  {Required: true},
+			"magic": {Kind: FieldBool},
+			"cache": {Kind: FieldBool},
+			"proxy": {Kind: FieldBool},
+		}},
+		"video": {Fields: map[string]Field{"file": {Required: true}}},
+		"file":  {Fields: map[string]Field{"file": {Required: true}}},
+		"at": {Fields: map[string]Field{"qq": {Required: true}}, Check: func(seg Segment) error {
+			if _, ok := seg.AsAt(); !ok {
+				return &FieldError{Type: "at", Field: "qq", Reason: "must be an integer or all"}
+			}
+			return nil
+		}},
+		"rps":   {},
+		"dice":  {},
+		"shake": {},
+		"poke":  {Fields: map[string]Field{"qq": {Kind: FieldInt, Required: true}}},
+		"share": {Fields: map[string]Field{"url": {Required: true}, "title": {Required: true}}},
+		"contact": {Fields: map[string]Field{
+			"type": {Kind: FieldEnum, Required: true, Enum: []string{"qq", "group"}},
+			"id":   {Kind: FieldInt, Required: true},
+		}},
+		"location": {Fields: map[string]Field{
+			"lat": {Kind: FieldFloat, Required: true},
+			"lon": {Kind: FieldFloat, Required: true},
+		}},
+		"music": {Fields: map[string]Field{
+			"type": {Kind: FieldEnum, Required: true, Enum: []string{"qq", "163", "xm", "custom"}},
+		}, Check: checkMusic},
+		"reply":   {Fields: map[string]Field{"id": {Required: true}}},
+		"forward": {Fields: map[string]Field{"id": {Required: true}}},
+		"node":    {Check: checkNode},
+		"xml":     {Fields: map[string]Field{"data": {Required: true}}},
+		"json": {Fields: map[string]Field{"data": {Required: true}}, Check: func(seg Segment) error {
+			if !json.Valid([]byte(seg.Data["data"])) {
+				return &FieldError{Type: "json", Field: "data", Reason: "invalid json"}
+			}
+			return nil
+		}},
+		"tts":      {Fields: map[string]Field{"text": {Required: true}}},
+		"markdown": {Fields: map[string]Field{"content": {Required: true}}},
+		"keyboard": {Fields: map[string]Field{"content": {Required: true}}, Check: func(seg Segment) error {
+			if _, ok := seg.AsKeyboard(); !ok {
+				return &FieldError{Type: "keyboard", Field: "content", Reason: "invalid keyboard json"}
+			}
+			return nil
+		}},
+		"longmsg": {Fields: map[string]Field{"id": {Required: true}}},
+		"mface": {Fields: map[string]Field{
+			"emoji_package_id": {Kind: FieldInt, Required: true},
+			"emoji_id":         {Required: true},
+			"key":              {Required: true},
+		}},
+	}
+)
+
+func checkMusic(seg Segment) error {
+	var errs []error
+	required := []string{"id"}
+	if seg.Data["type"] == "custom" {
+		required = []string{"url", "audio", "title"}
+	}
+	for _, k := range required {
+		if seg.Data[k] == "" {
+			errs = append(errs, &FieldError{Type: "music", Field: k, Reason: "is required"})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkNode(seg Segment) error {
+	if seg.Data["id"] != "" {
+		if _, err := strconv.ParseInt(seg.Data["id"], 10, 64); err != nil {
+			return &FieldError{Type: "node", Field: "id", Reason: "must be an integer"}
+		}
+		return nil
+	}
+	if seg.Data["content"] == "" {
+		return &FieldError{Type: "node", Field: "content", Reason: "id or content is required"}
+	}
+	return nil
+}
+
+// RegisterSchema 注册或覆盖 typ 类型消息段的规范
+func RegisterSchema(typ string, s Schema) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	schemas[typ] = s
+}
+
+// Validate 按规范校验消息段, 未注册规范的类型总是有效
+//
+// 返回的错误由所有无效字段的 *FieldError 合并而成
+func (m Segment) Validate() error {
+	if m.Type == "" {
+		return &FieldError{Reason: "empty segment type"}
+	}
+	schemaMu.RLock()
+	s, ok := schemas[m.Type]
+	schemaMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(s.Fields))
+	for k := range s.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs []error
+	for _, k := range keys {
+		if err := s.Fields[k].check(m.Type, k, m.Data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.Check != nil {
+		errs = append(errs, flattenErrors(s.Check(m))...)
+	}
+	return errors.Join(errs...)
+}
+
+func (f Field) check(typ, key string, data map[string]string) error {
+	v, ok := data[key]
+	if !ok || v == "" {
+		if f.Required && !(ok && f.AllowEmpty) {
+			return &FieldError{Type: typ, Field: key, Reason: "is required"}
+		}
+		return nil
+	}
+	var err error
+	switch f.Kind {
+	case FieldInt:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case FieldFloat:
+		_, err = strconv.ParseFloat(v, 64)
+	case FieldBool:
+		_, err = strconv.ParseBool(v)
+	case FieldEnum:
+		for _, e := range f.Enum {
+			if v == e {
+				return nil
+			}
+		}
+		return &FieldError{Type: typ, Field: key, Reason: fmt.Sprintf("must be one of %v, got %q", f.Enum, v)}
+	}
+	if err != nil {
+		return &FieldError{Type: typ, Field: key, Reason: fmt.Sprintf("invalid value %q", v)}
+	}
+	return nil
+}
+
+// Validate 校验所有消息段, 返回的错误中包含消息段的下标
+func (m Message) Validate() error {
+	var errs []error
+	for i, seg := range m {
+		for _, err := range flattenErrors(seg.Validate()) {
+			errs = append(errs, fmt.Errorf("segment %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flattenErrors 展开 errors.Join 合并的错误
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
@@ -0,0 +1,118 @@
+package zero
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/wdvxdr1123/ZeroBot/utils/metrics"
+)
+
+// Metrics ZeroBot 运行时指标注册表, 可在其中注册插件自定义指标
+var Metrics = metrics.NewRegistry()
+
+var (
+	metricEvents = Metrics.NewCounterVec(
+		"zerobot_events_total", "收到的事件数",
+		"post_type", "detail_type",
+	)
+	metricTriggered = Metrics.NewCounterVec(
+		"zerobot_matcher_triggered_total", "Matcher 触发次数",
+		"engine", "matcher",
+	)
+	metricHandlerDuration = Metrics.NewHistogramVec(
+		"zerobot_handler_duration_seconds", "Matcher Handler 处理耗时",
+		nil, "engine", "matcher",
+	)
+	metricTimeouts = Metrics.NewCounterVec(
+		"zerobot_timeouts_total", "处理达到最大时延的次数",
+		"stage", "engine", "matcher",
+	)
+	metricAPICalls = Metrics.NewCounterVec(
+		"zerobot_api_calls_total", "API 调用次数",
+		"action", "status",
+	)
+	metricAPIDuration = Metrics.NewHistogramVec(
+		"zerobot_api_duration_seconds", "API 调用耗时",
+		nil, "action",
+	)
+	_ = Metrics.NewGaugeFunc(
+		"zerobot_pending_futures", "等待中的 FutureEvent 数",
+		func() float64 {
+			pendingLock.Lock()
+			defer pendingLock.Unlock()
+			return float64(len(pendingFutures))
+		},
+	)
+	_ = Metrics.NewGaugeFunc(
+		"zerobot_connected_bots", "已连接的 bot 数",
+		func() float64 {
+			n := 0
+			APICallers.Range(func(_ int64, _ APICaller) bool {
+				n++
+				return true
+			})
+			return float64(n)
+		},
+	)
+)
+
+// MetricsHandler 返回以 Prometheus 文本格式导出 Metrics 的 http.Handler
+func MetricsHandler() http.Handler {
+	return Metrics
+}
+
+// serveMetrics 在 addr 上监听 /metrics
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Metrics)
+	go func() {
+		log.Infoln("[metrics] 开始监听", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Errorln("[metrics] 监听", addr, "时出现错误:", err)
+		}
+	}()
+}
+
+// defaultEngineLabel 未设置名称的 Engine 的 engine 标签
+const defaultEngineLabel = "default"
+
+// matcherLabels 返回 matcher 的 engine 与 name 标签
+func matcherLabels(m *Matcher) (engine, name string) {
+	if m.Engine != nil {
+		engine = m.Engine.name
+	}
+	if engine == "" {
+		engine = defaultEngineLabel
+	}
+	return engine, m.Name
+}
+
+func observeTimeout(stage string, m *Matcher) {
+	engine, name := matcherLabels(m)
+	metricTimeouts.With(stage, engine, name).Inc()
+}
+
+// observeTriggered 在 Handler 开始执行前计数, 超时的 Handler 同样计入
+func observeTriggered(m *Matcher) {
+	engine, name := matcherLabels(m)
+	metricTriggered.With(engine, name).Inc()
+}
+
+func observeHandler(m *Matcher, start time.Time) {
+	engine, name := matcherLabels(m)
+	metricHandlerDuration.With(engine, name).Observe(time.Since(start).Seconds())
+}
+
+func observeAPI(action string, start time.Time, rsp APIResponse, err error) {
+	status := "ok"
+	switch {
+	case err != nil:
+		status = "error"
+	case rsp.RetCode != 0:
+		status = "failed"
+	}
+	metricAPICalls.With(action, status).Inc()
+	metricAPIDuration.With(action).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,24 @@
+package zero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestObserveHandler(t *testing.T) {
+	done := make(chan struct{})
+	slow := &Matcher{Name: "slow", Type: Type("message"), Engine: New(), Handler: func(ctx *Ctx) { <-done }}
+	defer close(done)
+
+	ctx := fakeCtx(message.Message{message.Text("hi")})
+	ctx.Event.PostType = "message"
+	match(ctx, []*Matcher{slow}, 10*time.Millisecond)
+
+	assert.Equal(t, 1.0, metricTriggered.With(defaultEngineLabel, "slow").Value(), "timed out handler counted")
+	assert.Equal(t, 1.0, metricTimeouts.With("Handler", defaultEngineLabel, "slow").Value())
+	assert.Zero(t, metricHandlerDuration.With(defaultEngineLabel, "slow").Count())
+}
@@ -0,0 +1,288 @@
+package zero
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+var idType = reflect.TypeOf(message.ID{})
+
+// ParseError 解析 State 时的错误, 包含出错的字段
+type ParseError struct {
+	Field string // 结构体字段名
+	Key   string // State 键
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return "parse state field " + e.Field + " (" + e.Key + "): " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseState 将 ctx.State 解析为 T, T 须为结构体
+//
+// 字段 tag:
+//
+//	zero:"key"      State 键, 可用 . 访问嵌套的 map、切片与结构体, 如 "regex_matched.1";
+//	                键不存在时返回错误, 写作 "key,omitempty" 时跳过该字段
+//	default:"..."   键不存在时的默认值
+//	validate:"..."  以逗号分隔的校验规则: required, min=N, max=N, oneof=a b c
+//
+// 值的类型不一致时会尝试转换, 如 string 与数字、bool、time.Duration、message.ID 之间
+func ParseState[T any](ctx *Ctx) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, errors.New("zero: ParseState requires a struct type, got " + rv.Type().String())
+	}
+	return v, parseState(ctx.State, rv)
+}
+
+// getDecoder 获取 t 的 decoder, 结果会被缓存
+func getDecoder(t reflect.Type) decoder {
+	if d, ok := decoderCache.Load(t); ok {
+		return d.(decoder)
+	}
+	modelDec := decoder{}
+	for i := 0; i < t.NumField(); i++ {
+		t1 := t.Field(i)
+		key, ok := t1.Tag.Lookup("zero")
+		if !ok {
+			continue
+		}
+		d := dec{index: i, name: t1.Name}
+		d.key, d.omitempty = strings.CutSuffix(key, ",omitempty")
+		d.def, d.hasDef = t1.Tag.Lookup("default")
+		if v := t1.Tag.Get("validate"); v != "" {
+			d.validate = strings.Split(v, ",")
+		}
+		modelDec = append(modelDec, d)
+	}
+	decoderCache.Store(t, modelDec)
+	return modelDec
+}
+
+func parseState(state State, rv reflect.Value) error {
+	for _, d := range getDecoder(rv.Type()) { // decoder类型非小内存，无法被编译器优化为快速拷贝
+		field := rv.Field(d.index)
+		val, ok := lookupState(state, d.key)
+		var err error
+		switch {
+		case ok:
+			err = assign(field, val)
+		case d.hasDef:
+			err = assign(field, d.def)
+		case !d.omitempty:
+			err = errors.New("key not found")
+		}
+		if err == nil {
+			err = validate(field, ok || d.hasDef, d.validate)
+		}
+		if err != nil {
+			return &ParseError{Field: d.name, Key: d.key, Err: err}
+		}
+	}
+	return nil
+}
+
+// lookupState 按 key 查找 State 中的值, key 可用 . 分隔访问嵌套的值
+func lookupState(state State, key string) (interface{}, bool) {
+	if v, ok := state[key]; ok {
+		return v, v != nil
+	}
+	parts := strings.Split(key, ".")
+	cur, ok := state[parts[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, part := range parts[1:] {
+		rv := reflect.ValueOf(cur)
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return nil, false
+			}
+			rv = rv.Elem()
+		}
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			rv = rv.MapIndex(reflect.ValueOf(part).Convert(rv.Type().Key()))
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= rv.Len() {
+				return nil, false
+			}
+			rv = rv.Index(i)
+		case reflect.Struct:
+			rv = rv.FieldByName(part)
+		default:
+			return nil, false
+		}
+		if !rv.IsValid() || !rv.CanInterface() {
+			return nil, false
+		}
+		cur = rv.Interface()
+	}
+	return cur, cur != nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// assign 将 src 转换为 dst 的类型并赋值
+func assign(dst reflect.Value, src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	st, dt := sv.Type(), dst.Type()
+	switch {
+	case st.AssignableTo(dt):
+		dst.Set(sv)
+	case dt == idType && st.Kind() == reflect.String:
+		dst.Set(reflect.ValueOf(message.NewMessageIDFromString(sv.String())))
+	case dt == idType && isNumber(st.Kind()) && st.Kind() < reflect.Float32:
+		dst.Set(reflect.ValueOf(message.NewMessageIDFromInteger(sv.Convert(reflect.TypeOf(int64(0))).Int())))
+	case st == idType && isNumber(dt.Kind()):
+		return assign(dst, src.(message.ID).ID())
+	case st.Kind() == reflect.String && dt.Kind() == reflect.Slice && dt.Elem().Kind() != reflect.Uint8:
+		dst.Set(reflect.Zero(dt))
+		for _, s := range ParseShell(sv.String()) {
+			if err := setString(dst, s); err != nil {
+				return err
+			}
+		}
+	case st.Kind() == reflect.String && settable(dt):
+		return setString(dst, sv.String())
+	case isNumber(st.Kind()) && isNumber(dt.Kind()):
+		return convertNumber(dst, sv)
+	case st.Kind() == reflect.Bool && dt.Kind() == reflect.Bool:
+		dst.SetBool(sv.Bool())
+	case dt.Kind() == reflect.String && (isNumber(st.Kind()) || st.Kind() == reflect.Bool):
+		dst.SetString(fmt.Sprint(src))
+	case (st.Kind() == reflect.Slice || st.Kind() == reflect.Array) && dt.Kind() == reflect.Slice:
+		s := reflect.MakeSlice(dt, sv.Len(), sv.Len())
+		for i := 0; i < sv.Len(); i++ {
+			if err := assign(s.Index(i), sv.Index(i).Interface()); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		dst.Set(s)
+	case dt.Kind() == reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dt.Elem()))
+		}
+		return assign(dst.Elem(), src)
+	default:
+		return fmt.Errorf("cannot convert %s to %s", st, dt)
+	}
+	return nil
+}
+
+// convertNumber 在数字类型之间转换, 并检查溢出
+func convertNumber(dst, src reflect.Value) error {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch {
+		case src.CanInt():
+			i = src.Int()
+		case src.CanUint():
+			if src.Uint() > 1<<63-1 {
+				return errors.New("value out of range")
+			}
+			i = int64(src.Uint())
+		default:
+			f := src.Float()
+			if f != float64(int64(f)) {
+				return fmt.Errorf("%v is not an integer", f)
+			}
+			i = int64(f)
+		}
+		if dst.OverflowInt(i) {
+			return errors.New("value out of range")
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch {
+		case src.CanInt():
+			if src.Int() < 0 {
+				return errors.New("value out of range")
+			}
+			u = uint64(src.Int())
+		case src.CanUint():
+			u = src.Uint()
+		default:
+			f := src.Float()
+			if f < 0 || f != float64(uint64(f)) {
+				return fmt.Errorf("%v is not an unsigned integer", f)
+			}
+			u = uint64(f)
+		}
+		if dst.OverflowUint(u) {
+			return errors.New("value out of range")
+		}
+		dst.SetUint(u)
+	default:
+		dst.Set(src.Convert(dst.Type()))
+	}
+	return nil
+}
+
+// validate 按规则校验已赋值的字段
+func validate(v reflect.Value, present bool, rules []string) error {
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			if !present || v.IsZero() {
+				return errors.New("is required")
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s rule %q", name, arg)
+			}
+			var n float64
+			switch v.Kind() {
+			case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+				n = float64(v.Len())
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				n = float64(v.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				n = float64(v.Uint())
+			case reflect.Float32, reflect.Float64:
+				n = v.Float()
+			default:
+				return fmt.Errorf("%s rule is not supported for %s", name, v.Type())
+			}
+			if name == "min" && n < limit {
+				return fmt.Errorf("must be at least %s", arg)
+			}
+			if name == "max" && n > limit {
+				return fmt.Errorf("must be at most %s", arg)
+			}
+		case "oneof":
+			s := fmt.Sprint(v.Interface())
+			if !contains(strings.Fields(arg), s) {
+				return fmt.Errorf("must be one of %s", arg)
+			}
+		case "":
+		default:
+			return fmt.Errorf("unknown validate rule %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package zero
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestParseState(t *testing.T) {
+	type args struct {
+		Count   int           `zero:"count" validate:"min=1,max=10"`
+		Enable  bool          `zero:"enable"`
+		Wait    time.Duration `zero:"wait" default:"5s"`
+		Reply   message.ID    `zero:"reply"`
+		Second  string        `zero:"regex_matched.1"`
+		Host    string        `zero:"flag.Host" validate:"required"`
+		Mode    string        `zero:"mode" default:"fast" validate:"oneof=fast slow"`
+		Words   []string      `zero:"args"`
+		Ratio   float32       `zero:"ratio"`
+		UserID  int64         `zero:"user"`
+		Missing *int          `zero:"missing,omitempty"`
+	}
+	ctx := &Ctx{State: State{
+		"count":         "3",
+		"enable":        "true",
+		"reply":         "12345",
+		"regex_matched": []string{"a b", "a", "b"},
+		"flag":          &struct{ Host string }{Host: "example.com"},
+		"args":          `x "y z"`,
+		"ratio":         1,
+		"user":          message.NewMessageIDFromInteger(42),
+	}}
+	v, err := ParseState[args](ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, args{
+		Count:  3,
+		Enable: true,
+		Wait:   5 * time.Second,
+		Reply:  message.NewMessageIDFromString("12345"),
+		Second: "a",
+		Host:   "example.com",
+		Mode:   "fast",
+		Words:  []string{"x", "y z"},
+		Ratio:  1,
+		UserID: 42,
+	}, v)
+
+	var perr *ParseError
+	ctx.State["count"] = "abc"
+	_, err = ParseState[args](ctx)
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "Count", perr.Field)
+
+	ctx.State["count"] = int64(11)
+	_, err = ParseState[args](ctx)
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "Count", perr.Field)
+
+	ctx.State["count"] = 1
+	ctx.State["mode"] = "medium"
+	_, err = ParseState[args](ctx)
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "Mode", perr.Field)
+
+	delete(ctx.State, "mode")
+	delete(ctx.State, "flag")
+	_, err = ParseState[args](ctx)
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "Host", perr.Field)
+
+	type strict struct {
+		N    int    `zero:"n"`
+		Name string `zero:"name"`
+	}
+	_, err = ParseState[strict](&Ctx{State: State{"name": "a"}})
+	assert.True(t, errors.As(err, &perr), "missing key")
+	assert.Equal(t, "N", perr.Field)
+	_, err = ParseState[strict](&Ctx{State: State{"n": 1, "name": []string{"a"}}})
+	assert.Error(t, err, "non-scalar to string")
+	v2, err := ParseState[strict](&Ctx{State: State{"n": 1, "name": 2.5}})
+	assert.NoError(t, err)
+	assert.Equal(t, "2.5", v2.Name)
+
+	type small struct {
+		N int8 `zero:"n"`
+	}
+	_, err = ParseState[small](&Ctx{State: State{"n": 300}})
+	assert.Error(t, err)
+	_, err = ParseState[int](ctx)
+	assert.Error(t, err)
+}
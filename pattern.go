@@ -119,8 +119,12 @@ func NewPattern(option *PatternOption) *Pattern {
 
 type PatternSegment struct {
 	typ      string
-	optional bool
+	min, max int // 重复次数, max 为 -1 时不限
+	rest     bool
 	parse    Parser
+	name     string   // As 或 DSL 中设置的名称
+	subnames []string // 文本正则的分组名称
+	command  bool     // 文本需带命令前缀, 解析前去除
 }
 
 type Parser func(msg *message.Segment) PatternParsed
@@ -131,50 +135,62 @@ func (p *Pattern) SetOptional(v ...bool) *Pattern {
 	if len(p.segments) == 0 {
 		panic("pattern is empty")
 	}
-	if len(v) == 1 {
-		p.segments[len(p.segments)-1].optional = v[0]
-	} else {
-		p.segments[len(p.segments)-1].optional = true
+	seg := &p.segments[len(p.segments)-1]
+	if len(v) == 0 || v[0] {
+		seg.min = 0
+	} else if seg.min == 0 {
+		seg.min = 1
 	}
 	return p
 }
 
+// Repeat set previous segment can be matched min to max times, max < 0 means unlimited,
+// the first match is stored as the result and all matches in PatternParsed.All
+// if Pattern is empty or min, max is invalid, panic
+func (p *Pattern) Repeat(min, max int) *Pattern {
+	if len(p.segments) == 0 {
+		panic("pattern is empty")
+	}
+	if min < 0 || max == 0 || max > 0 && max < min {
+		panic("invalid repeat range")
+	}
+	seg := &p.segments[len(p.segments)-1]
+	seg.min, seg.max = min, max
+	return p
+}
+
 // PatternParsed PatternRule parse result
 type PatternParsed struct {
 	Value any
 	Msg   *message.Segment
+	All   []PatternParsed // 可重复的 segment 的所有匹配结果
 }
 
 // Text 获取正则表达式匹配到的文本数组
 func (p PatternParsed) Text() []string {
-	if p.Value == nil {
-		return nil
-	}
-	return p.Value.([]string)
+	v, _ := p.Value.([]string)
+	return v
+}
+
+// str 以字符串获取 Value, 类型不符时返回空串
+func (p PatternParsed) str() string {
+	v, _ := p.Value.(string)
+	return v
 }
 
 // At 获取被@者ID
 func (p PatternParsed) At() string {
-	if p.Value == nil {
-		return ""
-	}
-	return p.Value.(string)
+	return p.str()
 }
 
 // Image 获取图片URL
 func (p PatternParsed) Image() string {
-	if p.Value == nil {
-		return ""
-	}
-	return p.Value.(string)
+	return p.str()
 }
 
 // Reply 获取被回复的消息ID
 func (p PatternParsed) Reply() string {
-	if p.Value == nil {
-		return ""
-	}
-	return p.Value.(string)
+	return p.str()
 }
 
 // Raw 获取原始消息
@@ -182,11 +198,21 @@ func (p PatternParsed) Raw() *message.Segment {
 	return p.Msg
 }
 
+// Rest 获取 Rest 匹配到的剩余消息
+func (p PatternParsed) Rest() message.Message {
+	v, _ := p.Value.(message.Message)
+	return v
+}
+
 func (p *Pattern) Add(typ string, optional bool, parse Parser) *Pattern {
 	pattern := &PatternSegment{
-		typ:      typ,
-		optional: optional,
-		parse:    parse,
+		typ:   typ,
+		min:   1,
+		max:   1,
+		parse: parse,
+	}
+	if optional {
+		pattern.min = 0
 	}
 	p.segments = append(p.segments, *pattern)
 	return p
@@ -195,20 +221,17 @@ func (p *Pattern) Add(typ string, optional bool, parse Parser) *Pattern {
 // Text use regex to search a 'text' segment
 func (p *Pattern) Text(regex string) *Pattern {
 	p.Add("text", false, NewTextParser(regex))
+	p.segments[len(p.segments)-1].subnames = regexp.MustCompile(regex).SubexpNames()
 	return p
 }
 
 // Command similar to `Text` but have a command prefix
+//
+// 命令前缀与 CommandRule 相同, 按事件所在群的前缀设置识别
 func (p *Pattern) Command(regex string) *Pattern {
 	re := regexp.MustCompile(regex)
 	p.Add("text", false, func(msg *message.Segment) PatternParsed {
-		s := msg.Data["text"]
-		s = strings.Trim(s, " \n\r\t")
-		if strings.HasPrefix(s, BotConfig.CommandPrefix) {
-			s = strings.TrimPrefix(s, BotConfig.CommandPrefix)
-		} else {
-			return PatternParsed{}
-		}
+		s := msg.Data["text"] // 已由 matchSegments 去除前缀
 		matchString := re.MatchString(s)
 		if matchString {
 			return PatternParsed{
@@ -219,6 +242,8 @@ func (p *Pattern) Command(regex string) *Pattern {
 
 		return PatternParsed{}
 	})
+	p.segments[len(p.segments)-1].subnames = re.SubexpNames()
+	p.segments[len(p.segments)-1].command = true
 	return p
 }
 
@@ -318,36 +343,106 @@ func NewAnyParser() Parser {
 	}
 }
 
+// Rest match all remaining segments, may be empty
+func (p *Pattern) Rest() *Pattern {
+	p.Add("any", false, NewAnyParser())
+	seg := &p.segments[len(p.segments)-1]
+	seg.rest, seg.min, seg.max = true, 0, -1
+	return p
+}
+
 func (s *PatternSegment) matchType(msg message.Segment) bool {
 	return s.typ == msg.Type || s.typ == "any"
 }
+
 func mustMatchAllPatterns(pattern Pattern) bool {
 	for _, p := range pattern.segments {
-		if p.optional {
+		if p.min == 0 {
 			return false
 		}
 	}
 	return true
 }
+
 func patternMatch(ctx *Ctx, pattern Pattern, msgs []message.Segment) bool {
-	if mustMatchAllPatterns(pattern) && len(pattern.segments) != len(msgs) {
+	patternState := make([]PatternParsed, len(pattern.segments))
+	if !matchSegments(ctx, pattern.segments, msgs, !mustMatchAllPatterns(pattern), patternState) {
 		return false
 	}
-	patternState := make([]PatternParsed, len(pattern.segments))
+	ctx.State[KeyPattern] = patternState
+	if named := namedParsed(pattern.segments, patternState); named != nil {
+		ctx.State[KeyPatternNamed] = named
+	}
+	return true
+}
 
-	j := 0
-	for i := range pattern.segments {
-		if j < len(msgs) && pattern.segments[i].matchType(msgs[j]) {
-			patternState[i] = pattern.segments[i].parse(&msgs[j])
+// matchSegments 回溯匹配, 每个 segment 优先尽可能多地匹配
+//
+// trailing 为 true 时允许消息末尾有未匹配的消息段
+func matchSegments(ctx *Ctx, segments []PatternSegment, msgs []message.Segment, trailing bool, state []PatternParsed) bool {
+	if len(segments) == 0 {
+		return trailing || len(msgs) == 0
+	}
+	seg := &segments[0]
+	if seg.rest {
+		for n := len(msgs); n >= 0; n-- {
+			rest := make(message.Message, n)
+			copy(rest, msgs[:n])
+			state[0] = PatternParsed{Value: rest}
+			if n > 0 {
+				state[0].Msg = &msgs[0]
+			}
+			if matchSegments(ctx, segments[1:], msgs[n:], trailing, state[1:]) {
+				return true
+			}
 		}
-		if patternState[i].Value == nil {
-			if pattern.segments[i].optional {
-				continue
+		return false
+	}
+	var matched []PatternParsed
+	for k := 0; k < len(msgs) && (seg.max < 0 || len(matched) < seg.max); k++ {
+		if !seg.matchType(msgs[k]) {
+			break
+		}
+		var parsed PatternParsed
+		if seg.command {
+			parsed = parseCommand(ctx, seg, &msgs[k])
+		} else {
+			parsed = seg.parse(&msgs[k])
+		}
+		if parsed.Value == nil {
+			break
+		}
+		matched = append(matched, parsed)
+	}
+	for n := len(matched); n >= seg.min; n-- {
+		state[0] = PatternParsed{}
+		if n > 0 {
+			state[0] = matched[0]
+			if seg.max != 1 {
+				state[0].All = matched[:n:n]
 			}
-			return false
 		}
-		j++
+		if matchSegments(ctx, segments[1:], msgs[n:], trailing, state[1:]) {
+			return true
+		}
 	}
-	ctx.State[KeyPattern] = patternState
-	return true
+	return false
+}
+
+// parseCommand 按 ctx 所在群的命令前缀去除 msg 的前缀后交给 seg 解析
+func parseCommand(ctx *Ctx, seg *PatternSegment, msg *message.Segment) PatternParsed {
+	text, ok := ctx.trimCommandPrefix(strings.Trim(msg.Data["text"], " \n\r\t"))
+	if !ok {
+		return PatternParsed{}
+	}
+	trimmed := message.Segment{Type: msg.Type, Data: make(map[string]string, len(msg.Data))}
+	for k, v := range msg.Data {
+		trimmed.Data[k] = v
+	}
+	trimmed.Data["text"] = text
+	parsed := seg.parse(&trimmed)
+	if parsed.Msg == &trimmed {
+		parsed.Msg = msg
+	}
+	return parsed
 }
@@ -31,7 +31,8 @@ func fakeCtx(msg message.Message) *Ctx {
 
 // copy from extension.PatternModel
 type PatternModel struct {
-	Matched []PatternParsed `zero:"pattern_matched"`
+	Matched []PatternParsed          `zero:"pattern_matched"`
+	Named   map[string]PatternParsed `zero:"pattern_named,omitempty"`
 }
 
 // Test Match
@@ -347,3 +348,95 @@ func TestAllParse(t *testing.T) {
 		})
 	}
 }
+
+func TestPattern_Repeat(t *testing.T) {
+	ats := message.Message{message.Text("kick"), message.At(1), message.At(2), message.At(3)}
+	tests := [...]struct {
+		msg      message.Message
+		pattern  *Pattern
+		expected bool
+	}{
+		{ats, NewPattern(nil).Text("kick").At().Repeat(1, -1), true},
+		{ats, NewPattern(nil).Text("kick").At().Repeat(1, 2), false},
+		{ats, NewPattern(nil).Text("kick").At().Repeat(1, 2).At(), true},
+		{ats, NewPattern(nil).Text("kick").At().Repeat(4, 5), false},
+		{ats[:1], NewPattern(nil).Text("kick").At().Repeat(0, -1), true},
+		// 可选的中间 segment 需要回溯
+		{ats[:2], NewPattern(nil).Text("kick").At().SetOptional().At(), true},
+		{ats, NewPattern(nil).Text("kick").Rest(), true},
+		{ats[:1], NewPattern(nil).Text("kick").Rest(), true},
+		{ats, NewPattern(nil).Text("kick").Rest().At(), true},
+	}
+	for i, v := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx := fakeCtx(v.msg)
+			assert.Equal(t, v.expected, v.pattern.AsRule()(ctx))
+		})
+	}
+
+	ctx := fakeCtx(ats)
+	assert.True(t, NewPattern(nil).Text("kick").At().Repeat(1, -1).AsRule()(ctx))
+	parsed := ctx.State[KeyPattern].([]PatternParsed)
+	assert.Equal(t, "1", parsed[1].At())
+	assert.Len(t, parsed[1].All, 3)
+	assert.Equal(t, "3", parsed[1].All[2].At())
+
+	ctx = fakeCtx(ats)
+	assert.True(t, NewPattern(nil).Text("kick").Rest().AsRule()(ctx))
+	parsed = ctx.State[KeyPattern].([]PatternParsed)
+	assert.Equal(t, ats[1:], parsed[1].Rest())
+
+	assert.Panics(t, func() { NewPattern(nil).Repeat(1, 1) })
+	assert.Panics(t, func() { NewPattern(nil).At().Repeat(2, 1) })
+}
+
+func TestPattern_SegmentTypes(t *testing.T) {
+	file := message.File("f.zip", "f.zip").Add("file_size", 2048)
+	card := message.JSON(`{"app":"com.tencent.miniapp","meta":{"detail":{"title":"hi"}}}`)
+	tests := [...]struct {
+		msg      message.Message
+		pattern  *Pattern
+		expected bool
+	}{
+		{message.Message{message.Face(1)}, NewPattern(nil).Face(), true},
+		{message.Message{message.Face(1)}, NewPattern(nil).Face(2, 3), false},
+		{message.Message{message.Face(3)}, NewPattern(nil).Face(2, 3), true},
+		{message.Message{message.Record("a.amr")}, NewPattern(nil).Record(), true},
+		{message.Message{message.Video("a.mp4")}, NewPattern(nil).Video(), true},
+		{message.Message{file}, NewPattern(nil).File(nil), true},
+		{message.Message{file}, NewPattern(nil).File(&FileFilter{Name: `\.zip$`, MaxSize: 4096}), true},
+		{message.Message{file}, NewPattern(nil).File(&FileFilter{MaxSize: 1024}), false},
+		{message.Message{file}, NewPattern(nil).File(&FileFilter{Name: `\.txt$`}), false},
+		{message.Message{message.Forward("abc")}, NewPattern(nil).Forward(), true},
+		{message.Message{card}, NewPattern(nil).JSON("meta.detail.title"), true},
+		{message.Message{card}, NewPattern(nil).JSON("meta.news"), false},
+		{message.Message{message.JSON("not json")}, NewPattern(nil).JSON(), false},
+		{message.Message{message.XML(`<msg serviceID="1"/>`)}, NewPattern(nil).XML(`serviceID="1"`), true},
+		{message.Message{message.XML(`<msg serviceID="2"/>`)}, NewPattern(nil).XML(`serviceID="1"`), false},
+		{message.Message{message.Poke(1)}, NewPattern(nil).Poke(), true},
+		{message.Message{message.Poke(1)}, NewPattern(nil).Poke(message.NewMessageIDFromInteger(2)), false},
+		{message.Message{message.Face(1)}, MustCompilePattern(`{face:1,2}`, nil), true},
+		{message.Message{file}, MustCompilePattern(`{file:\.zip$}`, nil), true},
+		{message.Message{card}, MustCompilePattern(`{json:meta.detail,app}`, nil), true},
+		{message.Message{message.Poke(1)}, MustCompilePattern(`{poke:2}`, nil), false},
+	}
+	for i, v := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, v.expected, v.pattern.AsRule()(fakeCtx(v.msg)))
+		})
+	}
+
+	ctx := fakeCtx(message.Message{message.Face(5), file, card, message.Poke(7)})
+	assert.True(t, NewPattern(nil).Face().File(nil).JSON().Poke().AsRule()(ctx))
+	parsed := ctx.State[KeyPattern].([]PatternParsed)
+	assert.Equal(t, 5, parsed[0].Face())
+	assert.Equal(t, PatternFile{File: "f.zip", Name: "f.zip", Size: 2048}, parsed[1].File())
+	assert.Equal(t, "hi", parsed[2].JSON().Get("meta.detail.title").String())
+	assert.Equal(t, "7", parsed[3].Poke())
+
+	// 类型不符时不会 panic
+	assert.Equal(t, -1, parsed[1].Face())
+	assert.Equal(t, "", parsed[0].At())
+	assert.Nil(t, parsed[0].Text())
+	assert.Equal(t, int64(-1), parsed[0].File().Size)
+}
@@ -0,0 +1,335 @@
+package zero
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// KeyPatternNamed 具名匹配结果在 State 中的键
+const KeyPatternNamed = "pattern_named"
+
+// patternBuilders 非文本占位符的构造函数, arg 为 {type:arg} 中的参数
+var patternBuilders = map[string]func(arg string) (Parser, error){
+	"at": func(arg string) (Parser, error) {
+		if arg == "" {
+			return NewAtParser(), nil
+		}
+		return NewAtParser(message.NewMessageIDFromString(arg)), nil
+	},
+	"image": noArgParser(NewImageParser),
+	"reply": noArgParser(NewReplyParser),
+	"any":   noArgParser(NewAnyParser),
+}
+
+func noArgParser(newParser func() Parser) func(arg string) (Parser, error) {
+	return func(arg string) (Parser, error) {
+		if arg != "" {
+			return nil, errors.New("does not accept an argument")
+		}
+		return newParser(), nil
+	}
+}
+
+// patternItem DSL 中的一项, 为字面文本或 {} 占位符
+type patternItem struct {
+	literal  string
+	name     string
+	typ      string
+	arg      string
+	optional bool
+	min, max int  // 重复次数, 无量词时为 1, 1
+	space    bool // 与前一项之间有空白
+}
+
+func (it *patternItem) isText() bool {
+	return it.typ == "" || it.typ == "text" || it.typ == "cmd"
+}
+
+// As 为上一个 segment 命名, 匹配结果可通过 State["pattern_named"] 按名称获取
+// if Pattern is empty, panic
+func (p *Pattern) As(name string) *Pattern {
+	if len(p.segments) == 0 {
+		panic("pattern is empty")
+	}
+	p.segments[len(p.segments)-1].name = name
+	return p
+}
+
+// MustCompilePattern 同 CompilePattern, 出错时 panic
+func MustCompilePattern(s string, option *PatternOption) *Pattern {
+	p, err := CompilePattern(s, option)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// CompilePattern 将文本形式的规则编译为 Pattern
+//
+//	{type}          匹配一个 type 类型的消息段, 如 {at} {image} {reply} {any} {record} {video} {forward}
+//	{type?}         可选
+//	{type:arg}      带参数, 如 {at:123456} {text:\d+} {face:1,2} {file:\.zip$} {json:meta.detail} {xml:regex} {poke:123456}
+//	{name=type}     具名, 结果保存在 State["pattern_named"][name]
+//	{cmd:regex}     带命令前缀的文本
+//	{type+} {type*} {type{n,m}}  重复匹配, 见 Pattern.Repeat
+//	{rest}          剩余的所有消息段, 见 Pattern.Rest
+//
+// 相邻的字面文本与 {text}/{cmd} 合并为同一个文本段的正则, 字面文本中的空白匹配任意空白,
+// 可用 \{ 输入字面的 {. 例如
+//
+//	CompilePattern(`/echo {n=text:\d+} {who=at?}`, nil)
+func CompilePattern(s string, option *PatternOption) (*Pattern, error) {
+	items, err := tokenizePattern(s)
+	if err != nil {
+		return nil, err
+	}
+	p := NewPattern(option)
+	for i := 0; i < len(items); {
+		if items[i].isText() {
+			j := i
+			for j < len(items) && items[j].isText() {
+				j++
+			}
+			if err := p.addTextItems(items[i:j]); err != nil {
+				return nil, err
+			}
+			i = j
+			continue
+		}
+		it := &items[i]
+		if it.typ == "rest" {
+			if it.arg != "" || it.min != 1 || it.max != 1 {
+				return nil, errors.New("zero: {rest} does not accept an argument or quantifier")
+			}
+			p.Rest()
+			p.segments[len(p.segments)-1].name = it.name
+			i++
+			continue
+		}
+		build, ok := patternBuilders[it.typ]
+		if !ok {
+			return nil, errors.New("zero: unknown pattern type " + it.typ)
+		}
+		parse, err := build(it.arg)
+		if err != nil {
+			return nil, errors.New("zero: pattern {" + it.typ + "}: " + err.Error())
+		}
+		p.Add(it.typ, false, parse).Repeat(it.min, it.max)
+		p.segments[len(p.segments)-1].name = it.name
+		i++
+	}
+	if len(p.segments) == 0 {
+		return nil, errors.New("zero: empty pattern")
+	}
+	return p, nil
+}
+
+func tokenizePattern(s string) ([]patternItem, error) {
+	var (
+		items []patternItem
+		lit   strings.Builder
+		space bool
+	)
+	flush := func() {
+		if lit.Len() > 0 {
+			items = append(items, patternItem{literal: lit.String(), space: space})
+			lit.Reset()
+			space = false
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '}'):
+			i++
+			lit.WriteByte(s[i])
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			flush()
+			space = len(items) > 0
+		case c == '{':
+			flush()
+			end, depth := -1, 0
+			for k := i; k < len(s) && end < 0; k++ {
+				switch s[k] {
+				case '\\':
+					k++
+				case '{':
+					depth++
+				case '}':
+					depth--
+					if depth == 0 {
+						end = k
+					}
+				}
+			}
+			if end < 0 {
+				return nil, errors.New("zero: unclosed { in pattern " + s)
+			}
+			it, err := parsePlaceholder(s[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			it.space = space
+			space = false
+			items = append(items, it)
+			i = end
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	flush()
+	return items, nil
+}
+
+var (
+	patternNameRegexp = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	patternTypeRegexp = regexp.MustCompile(`^(\w+)(\?|\*|\+|\{(\d+)(,(\d*))?\})?$`)
+)
+
+// parsePlaceholder 解析 {} 内的 [name=]type[quantifier][:arg]
+func parsePlaceholder(s string) (patternItem, error) {
+	it := patternItem{min: 1, max: 1}
+	head, arg, _ := strings.Cut(s, ":")
+	it.arg = arg
+	if name, typ, ok := strings.Cut(head, "="); ok {
+		if !patternNameRegexp.MatchString(name) {
+			return it, errors.New("zero: invalid pattern name " + name)
+		}
+		it.name, head = name, typ
+	}
+	m := patternTypeRegexp.FindStringSubmatch(strings.TrimSpace(head))
+	if m == nil {
+		return it, errors.New("zero: invalid pattern {" + s + "}")
+	}
+	it.typ = m[1]
+	switch {
+	case m[2] == "?":
+		it.min = 0
+	case m[2] == "*":
+		it.min, it.max = 0, -1
+	case m[2] == "+":
+		it.max = -1
+	case m[2] != "":
+		it.min, _ = strconv.Atoi(m[3])
+		switch {
+		case m[4] == "":
+			it.max = it.min
+		case m[5] == "":
+			it.max = -1
+		default:
+			it.max, _ = strconv.Atoi(m[5])
+		}
+		if it.max == 0 || it.max > 0 && it.max < it.min {
+			return it, errors.New("zero: invalid repeat range in {" + s + "}")
+		}
+	}
+	it.optional = it.min == 0
+	if it.typ == "command" {
+		it.typ = "cmd"
+	}
+	if it.isText() && it.max != 1 {
+		return it, errors.New("zero: {" + it.typ + "} only supports ? quantifier")
+	}
+	return it, nil
+}
+
+// addTextItems 将连续的字面文本与 {text}/{cmd} 合并为一个文本段
+func (p *Pattern) addTextItems(items []patternItem) error {
+	var (
+		sb       strings.Builder
+		command  bool
+		optional = true
+	)
+	space := func(i int) string {
+		if items[i].optional || items[i-1].optional {
+			return `\s*`
+		}
+		return `\s+`
+	}
+	sb.WriteByte('^')
+	for i := range items {
+		it := &items[i]
+		if i > 0 && it.space {
+			sb.WriteString(space(i))
+		}
+		if it.typ == "" {
+			optional = false
+			sb.WriteString(regexp.QuoteMeta(it.literal))
+			continue
+		}
+		if it.typ == "cmd" {
+			if i > 0 {
+				return errors.New("zero: {cmd} must be at the beginning of text")
+			}
+			command = true
+		}
+		inner := strings.TrimSuffix(strings.TrimPrefix(it.arg, "^"), "$")
+		if inner == "" {
+			inner = ".+?"
+		}
+		if _, err := regexp.Compile(inner); err != nil {
+			return errors.New("zero: pattern {" + it.typ + "}: " + err.Error())
+		}
+		if it.name != "" {
+			sb.WriteString("(?P<" + it.name + ">" + inner + ")")
+		} else {
+			sb.WriteString("(" + inner + ")")
+		}
+		if it.optional {
+			sb.WriteByte('?')
+		} else {
+			optional = false
+		}
+	}
+	sb.WriteByte('$')
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return errors.New("zero: pattern text: " + err.Error())
+	}
+	p.Add("text", optional, func(msg *message.Segment) PatternParsed {
+		s := msg.Data["text"]
+		if !command { // 带 {cmd} 时已由 matchSegments 去除前缀
+			s = strings.Trim(s, " \n\r\t")
+		}
+		if m := re.FindStringSubmatch(s); m != nil {
+			return PatternParsed{Value: m, Msg: msg}
+		}
+		return PatternParsed{}
+	})
+	p.segments[len(p.segments)-1].subnames = re.SubexpNames()
+	p.segments[len(p.segments)-1].command = command
+	return nil
+}
+
+// namedParsed 按名称收集匹配结果, 没有具名 segment 时返回 nil
+func namedParsed(segments []PatternSegment, parsed []PatternParsed) map[string]PatternParsed {
+	var named map[string]PatternParsed
+	set := func(name string, v PatternParsed) {
+		if named == nil {
+			named = make(map[string]PatternParsed, 4)
+		}
+		named[name] = v
+	}
+	for i := range segments {
+		if parsed[i].Value == nil {
+			continue
+		}
+		if segments[i].name != "" {
+			set(segments[i].name, parsed[i])
+		}
+		m, ok := parsed[i].Value.([]string)
+		if !ok {
+			continue
+		}
+		for k, name := range segments[i].subnames {
+			if name != "" && k < len(m) && m[k] != "" {
+				set(name, PatternParsed{Value: []string{m[k]}, Msg: parsed[i].Msg})
+			}
+		}
+	}
+	return named
+}
@@ -0,0 +1,68 @@
+package zero
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := [...]struct {
+		pattern  string
+		msg      message.Message
+		expected bool
+	}{
+		{`/echo {text}`, message.Message{message.Text("/echo hi there")}, true},
+		{`/echo {text}`, message.Message{message.Text("/echo")}, false},
+		{`/echo {text?}`, message.Message{message.Text("/echo")}, true},
+		{`/echo {text:^\d+$}`, message.Message{message.Text("/echo 123")}, true},
+		{`/echo {text:^\d+$}`, message.Message{message.Text("/echo abc")}, false},
+		{`/echo{text:\d{2}}`, message.Message{message.Text("/echo12")}, true},
+		{`/kick {at}`, message.Message{message.Text("/kick "), message.At(1)}, true},
+		{`/kick {at:2}`, message.Message{message.Text("/kick "), message.At(1)}, false},
+		{`/kick {at} {image?}`, message.Message{message.Text("/kick"), message.At(1)}, true},
+		{`/kick {at} {image?}`, message.Message{message.Text("/kick"), message.At(1), message.Image("a")}, true},
+		{`{cmd:echo} {text}`, message.Message{message.Text("echo a")}, true},
+		{`{reply} {any}`, message.Message{message.Reply(1), message.Face(1)}, true},
+		{`a\{b\}`, message.Message{message.Text("a{b}")}, true},
+		{`/kick {at+}`, message.Message{message.Text("/kick"), message.At(1), message.At(2)}, true},
+		{`/kick {at{1,2}} {image}`, message.Message{message.Text("/kick"), message.At(1), message.At(2), message.Image("a")}, true},
+		{`/kick {at{3}}`, message.Message{message.Text("/kick"), message.At(1), message.At(2)}, false},
+		{`/kick {at*} {rest}`, message.Message{message.Text("/kick"), message.Face(1)}, true},
+	}
+	for i, v := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			p, err := CompilePattern(v.pattern, &PatternOption{})
+			assert.NoError(t, err)
+			assert.Equal(t, v.expected, p.AsRule()(fakeCtx(v.msg)))
+		})
+	}
+
+	for _, bad := range []string{``, `{at`, `{foo}`, `{image:1}`, `{text:(}`, `a {cmd:x}`, `{1x=at}`, `{text+}`, `{at{2,1}}`, `{rest?}`} {
+		_, err := CompilePattern(bad, nil)
+		assert.Error(t, err, bad)
+	}
+	assert.Panics(t, func() { MustCompilePattern(`{foo}`, nil) })
+}
+
+func TestPattern_Named(t *testing.T) {
+	p := MustCompilePattern(`/give {n=text:\d+} {who=at} {pic=image?}`, &PatternOption{})
+	ctx := fakeCtx(message.Message{message.Text("/give 10 "), message.At(123)})
+	assert.True(t, p.AsRule()(ctx))
+	var model PatternModel
+	assert.NoError(t, ctx.Parse(&model))
+	assert.Equal(t, "10", model.Named["n"].Text()[0])
+	assert.Equal(t, "123", model.Named["who"].At())
+	assert.NotContains(t, model.Named, "pic")
+
+	p = NewPattern(&PatternOption{}).Text(`(?P<verb>\w+)`).As("cmd").At().As("target")
+	ctx = fakeCtx(message.Message{message.Text("poke"), message.At(1)})
+	assert.True(t, p.AsRule()(ctx))
+	named := ctx.State[KeyPatternNamed].(map[string]PatternParsed)
+	assert.Equal(t, []string{"poke", "poke"}, named["cmd"].Text())
+	assert.Equal(t, []string{"poke"}, named["verb"].Text())
+	assert.Equal(t, "1", named["target"].At())
+}
@@ -0,0 +1,312 @@
+package zero
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// PatternFile File 匹配到的文件信息
+type PatternFile struct {
+	File string
+	Name string
+	URL  string
+	Size int64 // 未知时为 -1
+}
+
+// FileFilter File 的过滤条件, 零值表示不限制
+type FileFilter struct {
+	Name    string // 文件名正则
+	MinSize int64
+	MaxSize int64
+}
+
+func init() {
+	patternBuilders["face"] = func(arg string) (Parser, error) {
+		var ids []int
+		for _, s := range strings.Split(arg, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return NewFaceParser(ids...), nil
+	}
+	patternBuilders["record"] = noArgParser(NewRecordParser)
+	patternBuilders["video"] = noArgParser(NewVideoParser)
+	patternBuilders["forward"] = noArgParser(NewForwardParser)
+	patternBuilders["file"] = func(arg string) (Parser, error) {
+		if arg == "" {
+			return NewFileParser(nil), nil
+		}
+		if _, err := regexp.Compile(arg); err != nil {
+			return nil, err
+		}
+		return NewFileParser(&FileFilter{Name: arg}), nil
+	}
+	patternBuilders["json"] = func(arg string) (Parser, error) {
+		if arg == "" {
+			return NewJSONParser(), nil
+		}
+		return NewJSONParser(strings.Split(arg, ",")...), nil
+	}
+	patternBuilders["xml"] = func(arg string) (Parser, error) {
+		if _, err := regexp.Compile(arg); err != nil {
+			return nil, err
+		}
+		return NewXMLParser(arg), nil
+	}
+	patternBuilders["poke"] = func(arg string) (Parser, error) {
+		if arg == "" {
+			return NewPokeParser(), nil
+		}
+		if _, err := strconv.ParseInt(arg, 10, 64); err != nil {
+			return nil, errors.New("invalid qq " + arg)
+		}
+		return NewPokeParser(message.NewMessageIDFromString(arg)), nil
+	}
+}
+
+// Face 获取表情ID, 类型不符时返回 -1
+func (p PatternParsed) Face() int {
+	if v, ok := p.Value.(int); ok {
+		return v
+	}
+	return -1
+}
+
+// Record 获取语音URL
+func (p PatternParsed) Record() string {
+	return p.str()
+}
+
+// Video 获取视频URL
+func (p PatternParsed) Video() string {
+	return p.str()
+}
+
+// File 获取文件信息
+func (p PatternParsed) File() PatternFile {
+	v, ok := p.Value.(PatternFile)
+	if !ok {
+		v.Size = -1
+	}
+	return v
+}
+
+// Forward 获取合并转发ID
+func (p PatternParsed) Forward() string {
+	return p.str()
+}
+
+// JSON 获取 JSON 消息内容
+func (p PatternParsed) JSON() gjson.Result {
+	v, _ := p.Value.(gjson.Result)
+	return v
+}
+
+// XML 获取 XML 消息内容
+func (p PatternParsed) XML() string {
+	return p.str()
+}
+
+// Poke 获取被戳者ID
+func (p PatternParsed) Poke() string {
+	return p.str()
+}
+
+// Face match a 'face' segment, if id is not empty, only match specific faces
+func (p *Pattern) Face(id ...int) *Pattern {
+	p.Add("face", false, NewFaceParser(id...))
+	return p
+}
+
+func NewFaceParser(id ...int) Parser {
+	return func(msg *message.Segment) PatternParsed {
+		face, err := strconv.Atoi(msg.Data["id"])
+		if err != nil {
+			return PatternParsed{}
+		}
+		matched := len(id) == 0
+		for _, v := range id {
+			matched = matched || v == face
+		}
+		if !matched {
+			return PatternParsed{}
+		}
+		return PatternParsed{
+			Value: face,
+			Msg:   msg,
+		}
+	}
+}
+
+// mediaURL 优先返回 url, 没有时返回 file
+func mediaURL(msg *message.Segment) string {
+	if u := msg.Data["url"]; u != "" {
+		return u
+	}
+	return msg.Data["file"]
+}
+
+// Record match a 'record' segment
+func (p *Pattern) Record() *Pattern {
+	p.Add("record", false, NewRecordParser())
+	return p
+}
+
+func NewRecordParser() Parser {
+	return func(msg *message.Segment) PatternParsed {
+		return PatternParsed{
+			Value: mediaURL(msg),
+			Msg:   msg,
+		}
+	}
+}
+
+// Video match a 'video' segment
+func (p *Pattern) Video() *Pattern {
+	p.Add("video", false, NewVideoParser())
+	return p
+}
+
+func NewVideoParser() Parser {
+	return func(msg *message.Segment) PatternParsed {
+		return PatternParsed{
+			Value: mediaURL(msg),
+			Msg:   msg,
+		}
+	}
+}
+
+// File match a 'file' segment, filter can be nil
+func (p *Pattern) File(filter *FileFilter) *Pattern {
+	p.Add("file", false, NewFileParser(filter))
+	return p
+}
+
+func NewFileParser(filter *FileFilter) Parser {
+	var re *regexp.Regexp
+	if filter != nil && filter.Name != "" {
+		re = regexp.MustCompile(filter.Name)
+	}
+	return func(msg *message.Segment) PatternParsed {
+		f := PatternFile{
+			File: msg.Data["file"],
+			Name: msg.Data["name"],
+			URL:  msg.Data["url"],
+			Size: -1,
+		}
+		if f.Name == "" {
+			f.Name = msg.Data["file_name"]
+		}
+		if size, err := strconv.ParseInt(msg.Data["file_size"], 10, 64); err == nil {
+			f.Size = size
+		}
+		if re != nil && !re.MatchString(f.Name) {
+			return PatternParsed{}
+		}
+		if filter != nil && (filter.MinSize > 0 || filter.MaxSize > 0) {
+			if f.Size < 0 || f.Size < filter.MinSize || filter.MaxSize > 0 && f.Size > filter.MaxSize {
+				return PatternParsed{}
+			}
+		}
+		return PatternParsed{
+			Value: f,
+			Msg:   msg,
+		}
+	}
+}
+
+// Forward match a 'forward' segment
+func (p *Pattern) Forward() *Pattern {
+	p.Add("forward", false, NewForwardParser())
+	return p
+}
+
+func NewForwardParser() Parser {
+	return func(msg *message.Segment) PatternParsed {
+		return PatternParsed{
+			Value: msg.Data["id"],
+			Msg:   msg,
+		}
+	}
+}
+
+// JSON match a 'json' segment, all gjson paths must exist
+func (p *Pattern) JSON(paths ...string) *Pattern {
+	p.Add("json", false, NewJSONParser(paths...))
+	return p
+}
+
+func NewJSONParser(paths ...string) Parser {
+	return func(msg *message.Segment) PatternParsed {
+		data := msg.Data["data"]
+		if !gjson.Valid(data) {
+			return PatternParsed{}
+		}
+		result := gjson.Parse(data)
+		for _, path := range paths {
+			if !result.Get(path).Exists() {
+				return PatternParsed{}
+			}
+		}
+		return PatternParsed{
+			Value: result,
+			Msg:   msg,
+		}
+	}
+}
+
+// XML match a 'xml' segment, the content must match regex if not empty
+func (p *Pattern) XML(regex string) *Pattern {
+	p.Add("xml", false, NewXMLParser(regex))
+	return p
+}
+
+func NewXMLParser(regex string) Parser {
+	var re *regexp.Regexp
+	if regex != "" {
+		re = regexp.MustCompile(regex)
+	}
+	return func(msg *message.Segment) PatternParsed {
+		data := msg.Data["data"]
+		if re != nil && !re.MatchString(data) {
+			return PatternParsed{}
+		}
+		return PatternParsed{
+			Value: data,
+			Msg:   msg,
+		}
+	}
+}
+
+// Poke match a 'poke' segment, if id is not empty, only match specific target
+func (p *Pattern) Poke(id ...message.ID) *Pattern {
+	if len(id) > 1 {
+		panic("poke pattern only support one id")
+	}
+	p.Add("poke", false, NewPokeParser(id...))
+	return p
+}
+
+func NewPokeParser(id ...message.ID) Parser {
+	return func(msg *message.Segment) PatternParsed {
+		if len(id) == 0 || len(id) == 1 && id[0].String() == msg.Data["qq"] {
+			return PatternParsed{
+				Value: msg.Data["qq"],
+				Msg:   msg,
+			}
+		}
+		return PatternParsed{}
+	}
+}
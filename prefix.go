@@ -0,0 +1,119 @@
+package zero
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// groupPrefixes 群命令前缀覆盖
+	groupPrefixes   map[int64][]string
+	groupPrefixesMu sync.RWMutex
+)
+
+var groupPrefixKey = []byte("prefix\x00")
+
+func init() {
+	loadGroupPrefixes()
+	onStorageChange = append(onStorageChange, loadGroupPrefixes)
+}
+
+func loadGroupPrefixes() {
+	m := map[int64][]string{}
+	getStorage().Iterator(func(k, v []byte) bool {
+		if len(k) == len(groupPrefixKey)+8 && bytes.HasPrefix(k, groupPrefixKey) {
+			m[int64(binary.BigEndian.Uint64(k[len(groupPrefixKey):]))] = strings.Split(string(v), "\x00")
+		}
+		return true
+	})
+	groupPrefixesMu.Lock()
+	groupPrefixes = m
+	groupPrefixesMu.Unlock()
+}
+
+// SetGroupCommandPrefixes 设置群 groupID 的命令前缀, 覆盖全局配置
+//
+// prefixes 为空时恢复为全局配置
+func SetGroupCommandPrefixes(groupID int64, prefixes ...string) {
+	k := binary.BigEndian.AppendUint64(append([]byte(nil), groupPrefixKey...), uint64(groupID))
+	groupPrefixesMu.Lock()
+	defer groupPrefixesMu.Unlock()
+	var err error
+	if len(prefixes) == 0 {
+		delete(groupPrefixes, groupID)
+		err = getStorage().Delete(k)
+	} else {
+		groupPrefixes[groupID] = append([]string(nil), prefixes...)
+		err = getStorage().Put(k, []byte(strings.Join(prefixes, "\x00")))
+	}
+	if err != nil {
+		log.Warnln("[bot] 保存群", groupID, "命令前缀时出现错误:", err)
+	}
+}
+
+// GroupCommandPrefixes 获取群 groupID 的命令前缀覆盖, 未设置时返回 false
+func GroupCommandPrefixes(groupID int64) ([]string, bool) {
+	groupPrefixesMu.RLock()
+	defer groupPrefixesMu.RUnlock()
+	p, ok := groupPrefixes[groupID]
+	return append([]string(nil), p...), ok
+}
+
+// CommandPrefixList 返回全局命令前缀, 即 CommandPrefix 与 CommandPrefixes 的合并
+func (op *Config) CommandPrefixList() []string {
+	prefixes := make([]string, 0, len(op.CommandPrefixes)+1)
+	if op.CommandPrefix != "" || len(op.CommandPrefixes) == 0 {
+		prefixes = append(prefixes, op.CommandPrefix)
+	}
+	prefixes = append(prefixes, op.CommandPrefixes...)
+	return prefixes
+}
+
+// defaultCommandPrefix 用于生成帮助等提示的全局命令前缀
+func defaultCommandPrefix() string {
+	return BotConfig.CommandPrefixList()[0]
+}
+
+// commandPrefix 用于生成提示的命令前缀, 即 ctx 所在群生效的第一个前缀
+func (ctx *Ctx) commandPrefix() string {
+	return ctx.CommandPrefixes()[0]
+}
+
+// CommandPrefixes 返回 ctx 所在群生效的命令前缀, ctx 为 nil 时返回全局命令前缀
+func (ctx *Ctx) CommandPrefixes() []string {
+	if ctx != nil && ctx.Event != nil && ctx.Event.GroupID != 0 {
+		if p, ok := GroupCommandPrefixes(ctx.Event.GroupID); ok {
+			return p
+		}
+	}
+	return BotConfig.CommandPrefixList()
+}
+
+// trimCommandPrefix 去除 msg 的命令前缀, 优先匹配最长的前缀
+//
+// 开启 AtMeNoPrefix 时, @机器人 或以昵称开头的消息可省略前缀
+func (ctx *Ctx) trimCommandPrefix(msg string) (string, bool) {
+	if s, ok := trimPrefixes(msg, ctx.CommandPrefixes()); ok {
+		return s, true
+	}
+	if BotConfig.AtMeNoPrefix && ctx.Event != nil && ctx.Event.IsToMe {
+		return msg, true
+	}
+	return "", false
+}
+
+func trimPrefixes(msg string, prefixes []string) (string, bool) {
+	prefixes = append([]string(nil), prefixes...)
+	sort.SliceStable(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return msg[len(prefix):], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,76 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestCommandPrefixes(t *testing.T) {
+	old := BotConfig
+	defer func() { BotConfig = old }()
+	BotConfig.CommandPrefix = "/"
+	BotConfig.CommandPrefixes = []string{"#", "//"}
+	assert.Equal(t, []string{"/", "#", "//"}, BotConfig.CommandPrefixList())
+
+	rule := CommandRule("echo")
+	check := func(gid int64, tome bool, text string) (string, bool) {
+		ctx := &Ctx{
+			Event: &Event{GroupID: gid, IsToMe: tome, Message: message.Message{message.Text(text)}},
+			State: State{},
+		}
+		ok := rule(ctx)
+		args, _ := ctx.State["args"].(string)
+		return args, ok
+	}
+
+	args, ok := check(1, false, "#echo hi")
+	assert.True(t, ok)
+	assert.Equal(t, "hi", args)
+	_, ok = check(1, false, "//echo hi")
+	assert.True(t, ok)
+	_, ok = check(1, false, "!echo hi")
+	assert.False(t, ok)
+	_, ok = check(1, true, "echo hi")
+	assert.False(t, ok)
+
+	BotConfig.AtMeNoPrefix = true
+	_, ok = check(1, true, "echo hi")
+	assert.True(t, ok)
+	_, ok = check(1, false, "echo hi")
+	assert.False(t, ok)
+
+	SetGroupCommandPrefixes(2, "!")
+	defer SetGroupCommandPrefixes(2)
+	p, ok := GroupCommandPrefixes(2)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"!"}, p)
+	_, ok = check(2, false, "!echo hi")
+	assert.True(t, ok)
+	_, ok = check(2, false, "/echo hi")
+	assert.False(t, ok)
+	_, ok = check(1, false, "!echo hi")
+	assert.False(t, ok)
+	for _, r := range []Rule{NewPattern(nil).Command(`echo`).AsRule(), MustCompilePattern(`{cmd:echo}`, nil).AsRule()} {
+		rule = r
+		_, ok = check(2, false, "!echo")
+		assert.True(t, ok, "pattern uses group prefixes")
+		_, ok = check(2, false, "/echo")
+		assert.False(t, ok)
+		_, ok = check(1, false, "/echo")
+		assert.True(t, ok)
+	}
+
+	list := []string{"/", "//"}
+	_, _ = trimPrefixes("//echo", list)
+	assert.Equal(t, []string{"/", "//"}, list, "argument not sorted in place")
+
+	loadGroupPrefixes()
+	_, ok = GroupCommandPrefixes(2)
+	assert.True(t, ok)
+	SetGroupCommandPrefixes(2)
+	_, ok = GroupCommandPrefixes(2)
+	assert.False(t, ok)
+}
@@ -0,0 +1,144 @@
+package zero
+
+import (
+	"encoding/json"
+)
+
+// MatcherMeta Matcher 的描述信息
+type MatcherMeta struct {
+	Description string   `json:"description,omitempty"` // 功能描述
+	Usage       string   `json:"usage,omitempty"`       // 用法
+	Examples    []string `json:"examples,omitempty"`    // 示例
+	Category    string   `json:"category,omitempty"`    // 分类
+}
+
+// MatcherInfo 是已注册 Matcher 的只读快照
+type MatcherInfo struct {
+	Name     string       `json:"name"`
+	Engine   string       `json:"engine"`
+	Type     string       `json:"type"`
+	Priority int          `json:"priority"`
+	Block    bool         `json:"block"`
+	Temp     bool         `json:"temp"`
+	Commands []string     `json:"commands,omitempty"`
+	Rules    []string     `json:"rules"`
+	Meta     *MatcherMeta `json:"meta,omitempty"`
+}
+
+// setMeta 在 matcherLock 下修改 Meta
+func (m *Matcher) setMeta(set func(meta *MatcherMeta)) *Matcher {
+	matcherLock.Lock()
+	defer matcherLock.Unlock()
+	if m.Meta == nil {
+		m.Meta = &MatcherMeta{}
+	}
+	set(m.Meta)
+	return m
+}
+
+// SetName 设置 Matcher 名称
+func (m *Matcher) SetName(name string) *Matcher {
+	m.Name = name
+	return m
+}
+
+// SetDescription 设置功能描述
+func (m *Matcher) SetDescription(desc string) *Matcher {
+	return m.setMeta(func(meta *MatcherMeta) { meta.Description = desc })
+}
+
+// SetUsage 设置用法
+func (m *Matcher) SetUsage(usage string) *Matcher {
+	return m.setMeta(func(meta *MatcherMeta) { meta.Usage = usage })
+}
+
+// AddExamples 添加示例
+func (m *Matcher) AddExamples(examples ...string) *Matcher {
+	return m.setMeta(func(meta *MatcherMeta) { meta.Examples = append(meta.Examples, examples...) })
+}
+
+// SetCategory 设置分类
+func (m *Matcher) SetCategory(category string) *Matcher {
+	return m.setMeta(func(meta *MatcherMeta) { meta.Category = category })
+}
+
+// Commands 返回注册时的命令
+func (m *Matcher) Commands() []string {
+	matcherLock.RLock()
+	defer matcherLock.RUnlock()
+	return append([]string(nil), m.commands...)
+}
+
+// rules 返回 Rules 的快照, 供在锁外求值
+func (m *Matcher) rules() []Rule {
+	matcherLock.RLock()
+	defer matcherLock.RUnlock()
+	return append([]Rule(nil), m.Rules...)
+}
+
+// Info 返回 Matcher 的只读快照
+func (m *Matcher) Info() MatcherInfo {
+	matcherLock.RLock()
+	defer matcherLock.RUnlock()
+	info := MatcherInfo{
+		Name:     m.Name,
+		Type:     m.typ,
+		Priority: m.Priority,
+		Block:    m.Block,
+		Temp:     m.Temp,
+		Commands: append([]string(nil), m.commands...),
+		Rules:    make([]string, len(m.Rules)),
+	}
+	if m.Engine != nil {
+		info.Engine = m.Engine.name
+	}
+	for i, r := range m.Rules {
+		info.Rules[i] = ruleName(r)
+	}
+	if m.Meta != nil {
+		meta := *m.Meta
+		meta.Examples = append([]string(nil), meta.Examples...)
+		info.Meta = &meta
+	}
+	return info
+}
+
+// rangeMatchers 按优先级遍历已注册的 Matcher
+func rangeMatchers(iter func(m *Matcher) bool) {
+	matcherLock.RLock()
+	list := make([]*Matcher, len(matcherList))
+	copy(list, matcherList)
+	matcherLock.RUnlock()
+	for _, m := range list {
+		if !iter(m) {
+			return
+		}
+	}
+}
+
+// Matchers 按优先级返回所有已注册的 Matcher 的快照
+func Matchers() []MatcherInfo {
+	infos := make([]MatcherInfo, 0, 16)
+	rangeMatchers(func(m *Matcher) bool {
+		infos = append(infos, m.Info())
+		return true
+	})
+	return infos
+}
+
+// Matchers 按优先级返回该 Engine 已注册的 Matcher 的快照
+func (e *Engine) Matchers() []MatcherInfo {
+	infos := make([]MatcherInfo, 0, len(e.matchers))
+	rangeMatchers(func(m *Matcher) bool {
+		if m.Engine == e {
+			infos = append(infos, m.Info())
+		}
+		return true
+	})
+	return infos
+}
+
+// MatchersJSON 以 JSON 格式导出所有已注册的 Matcher
+func MatchersJSON() ([]byte, error) {
+	return json.Marshal(Matchers())
+}
@@ -0,0 +1,42 @@
+package zero
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	e := New().SetName("registry")
+	defer e.Delete()
+	e.OnCommandGroup([]string{"ping", "p"}, OnlyGroup).
+		SetName("ping").
+		SetDescription("检查存活").
+		SetCategory("工具").
+		AddExamples("/ping").
+		SetPriority(5)
+	e.OnRegex(`^hello$`).SetBlock(true)
+
+	infos := e.Matchers()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, "registry", infos[0].Engine)
+	assert.Equal(t, "message", infos[0].Type)
+	assert.Equal(t, []string{"ZeroBot.RegexRule"}, infos[0].Rules)
+	assert.True(t, infos[0].Block)
+	assert.Equal(t, "ping", infos[1].Name)
+	assert.Equal(t, 5, infos[1].Priority)
+	assert.Equal(t, []string{"ping", "p"}, infos[1].Commands)
+	assert.Equal(t, []string{"ZeroBot.CommandRule", "ZeroBot.OnlyGroup"}, infos[1].Rules)
+	assert.Equal(t, &MatcherMeta{Description: "检查存活", Category: "工具", Examples: []string{"/ping"}}, infos[1].Meta)
+
+	// 快照不影响原 Matcher
+	infos[1].Meta.Examples[0] = "changed"
+	assert.Equal(t, "/ping", e.Matchers()[1].Meta.Examples[0])
+
+	data, err := MatchersJSON()
+	assert.NoError(t, err)
+	var all []MatcherInfo
+	assert.NoError(t, json.Unmarshal(data, &all))
+	assert.GreaterOrEqual(t, len(all), 2)
+}
@@ -0,0 +1,39 @@
+package zero
+
+import (
+	"sync"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// Renderer 返回解析名称与被回复消息的 message.Renderer
+//
+// at 段渲染为群名片或昵称, 回复段渲染为被回复消息的内容, 名称在同一 Renderer 中缓存,
+// 返回的 Renderer 可在多个 goroutine 中共享
+func (ctx *Ctx) Renderer() *message.Renderer {
+	var mu sync.Mutex
+	names := map[int64]string{}
+	return &message.Renderer{
+		UserName: func(qq int64) string {
+			mu.Lock()
+			name, ok := names[qq]
+			mu.Unlock()
+			if ok {
+				return name
+			}
+			name = ctx.CardOrNickName(qq)
+			mu.Lock()
+			names[qq] = name
+			mu.Unlock()
+			return name
+		},
+		Reply: func(id string) message.Message {
+			return ctx.GetMessage(message.NewMessageIDFromString(id), true).Elements
+		},
+	}
+}
+
+// RenderMessage 将 m 渲染为便于阅读的文本, 见 Renderer
+func (ctx *Ctx) RenderMessage(m message.Message) string {
+	return ctx.Renderer().Render(m)
+}
@@ -0,0 +1,58 @@
+package zero
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// nameCaller 返回固定的群名片、昵称与消息
+type nameCaller struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *nameCaller) CallAPI(req APIRequest) (APIResponse, error) {
+	c.mu.Lock()
+	c.calls[req.Action]++
+	c.mu.Unlock()
+	switch req.Action {
+	case "get_group_member_info":
+		if req.Params["user_id"] == int64(1) {
+			return APIResponse{Data: gjson.Parse(`{"card":"群名片"}`)}, nil
+		}
+		return APIResponse{Data: gjson.Parse(`{"card":""}`)}, nil
+	case "get_stranger_info":
+		return APIResponse{Data: gjson.Parse(`{"nickname":"昵称"}`)}, nil
+	case "get_msg":
+		return APIResponse{Data: gjson.Parse(`{"message_id":7,"message":[{"type":"text","data":{"text":"原消息"}}],"sender":{"user_id":2}}`)}, nil
+	}
+	return APIResponse{}, nil
+}
+
+func TestRenderMessage(t *testing.T) {
+	c := &nameCaller{calls: map[string]int{}}
+	ctx := &Ctx{Event: &Event{GroupID: 10}, caller: c}
+	m := message.Message{message.Reply(7), message.At(1), message.At(2), message.At(1), message.Text("看"), message.Image("a")}
+	assert.Equal(t, "「原消息」\n@群名片 @昵称 @群名片 看[图片]", ctx.RenderMessage(m))
+	assert.Equal(t, 2, c.calls["get_group_member_info"], "names cached")
+	assert.Equal(t, 1, c.calls["get_stranger_info"])
+}
+
+func TestRendererConcurrent(t *testing.T) {
+	ctx := &Ctx{Event: &Event{GroupID: 10}, caller: &nameCaller{calls: map[string]int{}}}
+	r := ctx.Renderer()
+	done := make(chan string)
+	for i := 0; i < 4; i++ {
+		go func(qq int64) {
+			done <- r.Render(message.Message{message.At(qq)})
+		}(int64(i%2 + 1))
+	}
+	for i := 0; i < 4; i++ {
+		assert.Contains(t, []string{"@群名片 ", "@昵称 "}, <-done)
+	}
+}
@@ -0,0 +1,227 @@
+package zero
+
+import (
+	"strings"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// CommandNode 命令树节点, 用于注册形如 "/group ban 123" 的多级命令
+//
+// 匹配成功后, State 中
+//
+//	"subcommand" 为完整的命令路径, 如 "group ban"
+//	"args"       为剩余参数字符串
+//	"sub_args"   为经 ParseShell 切分的剩余参数
+type CommandNode struct {
+	name     string
+	aliases  []string
+	rules    []Rule
+	handler  Handler
+	children []*CommandNode
+	parent   *CommandNode
+	matcher  *Matcher // 仅根节点
+}
+
+// Command 创建命令树(默认Engine)
+func Command(name string, aliases ...string) *CommandNode {
+	return defaultEngine.Command(name, aliases...)
+}
+
+// Command 创建命令树, 返回根节点
+//
+//	engine.Command("group").Sub("ban", ban).Sub("unban", unban)
+func (e *Engine) Command(name string, aliases ...string) *CommandNode {
+	n := &CommandNode{name: name, aliases: aliases}
+	n.matcher = e.OnCommandGroup(n.names(), n.resolve).Handle(n.dispatch)
+	return n
+}
+
+// Matcher 返回命令树根节点的 Matcher
+func (n *CommandNode) Matcher() *Matcher {
+	return n.root().matcher
+}
+
+// Parent 返回父节点, 根节点返回 nil
+func (n *CommandNode) Parent() *CommandNode {
+	return n.parent
+}
+
+// Sub 添加子命令, 返回当前节点以便链式调用
+func (n *CommandNode) Sub(name string, handler Handler, rules ...Rule) *CommandNode {
+	n.Node(name).Use(rules...).Handle(handler)
+	return n
+}
+
+// Node 添加子命令节点并返回该子节点, 用于嵌套子命令
+func (n *CommandNode) Node(name string, aliases ...string) *CommandNode {
+	child := &CommandNode{name: name, aliases: aliases, parent: n}
+	n.children = append(n.children, child)
+	child.index(child.names()...)
+	return child
+}
+
+// Alias 为当前节点添加别名
+func (n *CommandNode) Alias(aliases ...string) *CommandNode {
+	n.aliases = append(n.aliases, aliases...)
+	if n.matcher != nil {
+		// match 与 Matcher.copy 不加锁读取 Rules, 因此替换为新的切片而不是原地修改
+		matcherLock.Lock()
+		n.matcher.Rules = append([]Rule{CommandRule(n.names()...)}, n.matcher.Rules[1:]...)
+		n.matcher.commands = append(append([]string(nil), n.matcher.commands...), aliases...)
+		matcherLock.Unlock()
+	} else {
+		n.index(aliases...)
+	}
+	return n
+}
+
+// index 将子节点的名称 names 以 "父路径 名称" 的形式加入根节点的 commands,
+// 以便帮助菜单与命令纠错使用
+func (n *CommandNode) index(names ...string) {
+	m := n.root().matcher
+	prefix := n.parent.path() + " "
+	matcherLock.Lock()
+	commands := append([]string(nil), m.commands...)
+	for _, name := range names {
+		commands = append(commands, prefix+name)
+	}
+	m.commands = commands
+	matcherLock.Unlock()
+}
+
+// Handle 设置当前节点的处理函数, 没有匹配的子命令时调用
+func (n *CommandNode) Handle(handler Handler) *CommandNode {
+	n.handler = handler
+	return n
+}
+
+// Use 为当前节点及其子节点添加权限等 Rule, 未通过时视为未匹配, 不做任何回复
+//
+// 根节点的 Rule 会加入 Matcher.Rules, 帮助菜单等据此判断权限
+func (n *CommandNode) Use(rules ...Rule) *CommandNode {
+	n.rules = append(n.rules, rules...)
+	if n.matcher != nil {
+		// 插入在 resolve 之前, 同样替换为新的切片
+		matcherLock.Lock()
+		last := len(n.matcher.Rules) - 1
+		r := make([]Rule, 0, len(n.matcher.Rules)+len(rules))
+		r = append(r, n.matcher.Rules[:last]...)
+		r = append(r, rules...)
+		n.matcher.Rules = append(r, n.matcher.Rules[last])
+		matcherLock.Unlock()
+	}
+	return n
+}
+
+func (n *CommandNode) root() *CommandNode {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+func (n *CommandNode) names() []string {
+	return append([]string{n.name}, n.aliases...)
+}
+
+func (n *CommandNode) path() string {
+	if n.parent == nil {
+		return n.name
+	}
+	return n.parent.path() + " " + n.name
+}
+
+func (n *CommandNode) child(name string) *CommandNode {
+	for _, c := range n.children {
+		for _, alias := range c.names() {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// find 按 path 查找子孙节点
+func (n *CommandNode) find(path string) *CommandNode {
+	node := n
+	for _, name := range strings.Fields(path)[1:] {
+		if node = node.child(name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func (n *CommandNode) check(ctx *Ctx) bool {
+	for _, rule := range n.rules {
+		if !rule(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve 作为根节点 Matcher 的最后一条 Rule, 在匹配成功前找到目标节点,
+// 途经的子节点 Rule 未通过时视为未匹配
+func (n *CommandNode) resolve(ctx *Ctx) bool {
+	args, _ := ctx.State["args"].(string)
+	fields := ParseShell(args)
+	node := n
+	for len(fields) > 0 {
+		child := node.child(fields[0])
+		if child == nil {
+			break
+		}
+		if rest := strings.TrimPrefix(args, fields[0]); rest != args {
+			args = strings.TrimLeft(rest, " \t\r\n")
+		} else {
+			args = strings.Join(fields[1:], " ")
+		}
+		node, fields = child, fields[1:]
+		if !node.check(ctx) {
+			return false
+		}
+	}
+	ctx.State["subcommand"] = node.path()
+	ctx.State["args"] = args
+	ctx.State["sub_args"] = fields
+	return true
+}
+
+func (n *CommandNode) dispatch(ctx *Ctx) {
+	path, _ := ctx.State["subcommand"].(string)
+	node := n.find(path)
+	if node == nil {
+		return
+	}
+	fields, _ := ctx.State["sub_args"].([]string)
+	if node.handler != nil {
+		node.handler(ctx)
+		return
+	}
+	if len(node.children) == 0 {
+		return
+	}
+	names := make([]string, 0, len(node.children))
+	for _, c := range node.children {
+		if c.check(ctx) { // 只提示有权使用的子命令
+			names = append(names, c.names()...)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	if len(fields) == 0 {
+		ctx.SendChain(message.Text("请指定子命令: ", strings.Join(names, ", ")))
+		return
+	}
+	reply := "未知子命令: " + fields[0]
+	if s := suggest(fields[0], names, 0.5); len(s) > 0 {
+		reply += ", 你是不是想输入: " + ctx.commandPrefix() + node.path() + " " + s[0]
+	} else {
+		reply += ", 可用的子命令: " + strings.Join(names, ", ")
+	}
+	ctx.SendChain(message.Text(reply))
+}
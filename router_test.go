@@ -0,0 +1,131 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+type recordCaller struct {
+	requests []APIRequest
+}
+
+func (r *recordCaller) CallAPI(req APIRequest) (APIResponse, error) {
+	r.requests = append(r.requests, req)
+	return APIResponse{Data: gjson.Parse(`{"message_id":1}`)}, nil
+}
+
+// sent 返回最后一次发送的文本
+func (r *recordCaller) sent() string {
+	if len(r.requests) == 0 {
+		return ""
+	}
+	m, _ := r.requests[len(r.requests)-1].Params["message"].(message.Message)
+	return m.ExtractPlainText()
+}
+
+// checkRules 以 m 的副本 (同 match) 检查私聊消息 text 是否满足 m 的 Rules
+func checkRules(m *Matcher, text string) (*Ctx, *recordCaller, bool) {
+	return checkEvent(m, &Event{PostType: "message", DetailType: "private", UserID: 1, Message: message.Message{message.Text(text)}})
+}
+
+// checkEvent 以 m 的副本 (同 match) 检查 event 是否满足 m 的 Rules
+func checkEvent(m *Matcher, event *Event) (*Ctx, *recordCaller, bool) {
+	rec := &recordCaller{}
+	ctx := &Ctx{
+		Event:  event,
+		State:  State{},
+		caller: rec,
+		ma:     m.copy(),
+	}
+	for _, rule := range m.Rules {
+		if !rule(ctx) {
+			return ctx, rec, false
+		}
+	}
+	return ctx, rec, true
+}
+
+func runMatcher(m *Matcher, text string) (*Ctx, *recordCaller) {
+	ctx, rec, ok := checkRules(m, text)
+	if !ok {
+		return nil, rec
+	}
+	m.Handler(ctx)
+	return ctx, rec
+}
+
+func TestCommandNode(t *testing.T) {
+	e := New()
+	defer e.Delete()
+	var got []string
+	record := func(ctx *Ctx) {
+		got = append(got, ctx.State["subcommand"].(string)+"|"+ctx.State["args"].(string))
+	}
+	deny := func(ctx *Ctx) bool { return false }
+	root := e.Command("group", "群").
+		Sub("ban", record).
+		Sub("unban", record).
+		Sub("kick", record, deny)
+	root.Node("title", "头衔").Sub("set", record).Handle(record)
+
+	root.Node("mute").Alias("禁言")
+	assert.Equal(t, []string{
+		"group", "群", "group ban", "group unban", "group kick",
+		"group title", "group 头衔", "group title set", "group mute", "group 禁言",
+	}, root.Matcher().Commands(), "aliases of sub nodes are indexed")
+
+	runMatcher(root.Matcher(), "group ban 123 1h")
+	runMatcher(root.Matcher(), "群 头衔 set \"a b\"")
+	runMatcher(root.Matcher(), "group title")
+	runMatcher(root.Matcher(), "group kick 123")
+	assert.Equal(t, []string{"group ban|123 1h", "group title set|\"a b\"", "group title|"}, got)
+	_, _, ok := checkRules(root.Matcher(), "group kick 123")
+	assert.False(t, ok, "node rules reject before the matcher is consumed")
+
+	_, rec := runMatcher(root.Matcher(), "group bna 123")
+	assert.Equal(t, "未知子命令: bna, 你是不是想输入: group ban", rec.sent())
+	_, rec = runMatcher(root.Matcher(), "group")
+	assert.Equal(t, "请指定子命令: ban, unban, title, 头衔, mute, 禁言", rec.sent())
+	_, rec = runMatcher(root.Matcher(), "group kcik")
+	assert.Equal(t, "未知子命令: kcik, 可用的子命令: ban, unban, title, 头衔, mute, 禁言", rec.sent(), "no suggestion without permission")
+
+	SetGroupCommandPrefixes(7, "!")
+	defer SetGroupCommandPrefixes(7)
+	ctx, rec, ok := checkEvent(root.Matcher(), &Event{
+		PostType: "message", DetailType: "group", GroupID: 7, UserID: 1,
+		Message: message.Message{message.Text("!group bna")},
+	})
+	assert.True(t, ok)
+	root.Matcher().Handler(ctx)
+	assert.Equal(t, "未知子命令: bna, 你是不是想输入: !group ban", rec.sent(), "suggestion uses group prefix")
+
+	before := root.Matcher().Rules
+	root.Alias("g")
+	assert.NotSame(t, &before[0], &root.Matcher().Rules[0], "rules replaced, not modified in place")
+	ctx, _ = runMatcher(root.Matcher(), "g unban 1")
+	assert.Equal(t, []string{"1"}, ctx.State["sub_args"])
+}
+
+func TestCommandNode_Use(t *testing.T) {
+	e := New()
+	defer e.Delete()
+	root := e.Command("admin").Use(AdminPermission).Sub("ping", func(ctx *Ctx) {})
+	root.Alias("adm")
+	event := func(uid int64, role string) *Event {
+		return &Event{
+			PostType: "message", DetailType: "group", GroupID: 1, UserID: uid,
+			Sender: &User{ID: uid, Role: role}, Message: message.Message{message.Text("adm ping")},
+		}
+	}
+	member, _, ok := checkEvent(root.Matcher(), event(2, "member"))
+	assert.False(t, ok)
+	assert.False(t, canRun(member, root.Matcher()), "root rules are visible to help")
+	admin, _, ok := checkEvent(root.Matcher(), event(3, "admin"))
+	assert.True(t, ok)
+	assert.True(t, canRun(admin, root.Matcher()))
+	assert.Equal(t, "admin ping", admin.State["subcommand"])
+}
@@ -91,10 +91,10 @@ func CommandRule(commands ...string) Rule {
 			return false
 		}
 		msg := strings.TrimSpace(ctx.ExtractPlainText())
-		if !strings.HasPrefix(msg, BotConfig.CommandPrefix) {
+		cmdMessage, ok := ctx.trimCommandPrefix(msg)
+		if !ok {
 			return false
 		}
-		cmdMessage := msg[len(BotConfig.CommandPrefix):]
 		for _, command := range commands {
 			if strings.HasPrefix(cmdMessage, command+" ") || strings.TrimSpace(cmdMessage) == command {
 				ctx.State["command"] = command
@@ -109,16 +109,43 @@ func CommandRule(commands ...string) Rule {
 }
 
 // RegexRule check if the message can be matched by the regex pattern
+//
+// 匹配对象为含 CQ 码的消息字符串, 具名分组保存在 State["regex_named"]
 func RegexRule(regexPattern string) Rule {
 	regex := regexp.MustCompile(regexPattern)
 	return func(ctx *Ctx) bool {
-		msg := ctx.MessageString()
-		if matched := regex.FindStringSubmatch(msg); matched != nil {
-			ctx.State["regex_matched"] = matched
-			return true
-		}
+		return regexMatch(ctx, regex, ctx.MessageString())
+	}
+}
+
+// PlainRegexRule 与 RegexRule 相同, 但只匹配消息中的纯文本
+func PlainRegexRule(regexPattern string) Rule {
+	regex := regexp.MustCompile(regexPattern)
+	return func(ctx *Ctx) bool {
+		return regexMatch(ctx, regex, ctx.ExtractPlainText())
+	}
+}
+
+func regexMatch(ctx *Ctx, regex *regexp.Regexp, msg string) bool {
+	matched := regex.FindStringSubmatch(msg)
+	if matched == nil {
 		return false
 	}
+	ctx.State["regex_matched"] = matched
+	var named map[string]string
+	for i, name := range regex.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if named == nil {
+			named = make(map[string]string, 4)
+		}
+		named[name] = matched[i]
+	}
+	if named != nil {
+		ctx.State["regex_named"] = named
+	}
+	return true
 }
 
 // ReplyRule check if the message is replying some message
@@ -310,13 +337,13 @@ func MustProvidePicture(ctx *Ctx) bool {
 	}
 	// 没有图片就索取
 	ctx.SendChain(message.Text("请发送一张图片"))
-	next := NewFutureEvent("message", 999, true, ctx.CheckSession(), HasPicture).Next()
-	select {
-	case <-time.After(time.Second * 120):
+	newCtx := <-NewFutureEvent("message", 999, true, ctx.CheckSession(), HasPicture).
+		SetSession(sessionKey(ctx)).
+		NextWithTimeout(time.Second * 120)
+	if newCtx == nil {
 		return false
-	case newCtx := <-next:
-		ctx.State["image_url"] = newCtx.State["image_url"]
-		ctx.Event.MessageID = newCtx.Event.MessageID
-		return true
 	}
+	ctx.State["image_url"] = newCtx.State["image_url"]
+	ctx.Event.MessageID = newCtx.Event.MessageID
+	return true
 }
@@ -0,0 +1,36 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestRegexRule(t *testing.T) {
+	msg := message.Message{message.Text("天气 "), message.Face(1), message.Text("北京")}
+	newCtx := func() *Ctx {
+		return &Ctx{Event: &Event{Message: msg}, State: State{}}
+	}
+	const pattern = `^天气\s*(?P<city>\p{Han}+)$`
+
+	ctx := newCtx()
+	assert.True(t, RegexRule(`^天气 \[CQ:face,id=1\](?P<city>\S+)$`)(ctx))
+	assert.Equal(t, map[string]string{"city": "北京"}, ctx.State["regex_named"])
+	assert.False(t, RegexRule(pattern)(newCtx()))
+
+	ctx = newCtx()
+	assert.True(t, PlainRegexRule(pattern)(ctx))
+	assert.Equal(t, []string{"天气 北京", "北京"}, ctx.State["regex_matched"])
+
+	var city struct {
+		City string `zero:"regex_named.city"`
+	}
+	assert.NoError(t, ctx.Parse(&city))
+	assert.Equal(t, "北京", city.City)
+
+	ctx = newCtx()
+	assert.True(t, PlainRegexRule(`北京`)(ctx))
+	assert.NotContains(t, ctx.State, "regex_named")
+}
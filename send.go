@@ -0,0 +1,29 @@
+package zero
+
+import (
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// botNickname 合并转发节点使用的昵称
+func botNickname() string {
+	if len(BotConfig.NickName) > 0 {
+		return BotConfig.NickName[0]
+	}
+	return "ZeroBot"
+}
+
+// SendSplit 按 Config.MessageLimit 将 msg 拆分为多条依次发送, 返回各条消息的 ID
+func (ctx *Ctx) SendSplit(msg message.Message) []message.ID {
+	parts := message.Split(msg, BotConfig.MessageLimit)
+	ids := make([]message.ID, 0, len(parts))
+	for _, m := range parts {
+		ids = append(ids, ctx.send(m)) // 已拆分, 不再经过 LongReplyPolicy
+	}
+	return ids
+}
+
+// SendForward 按 Config.MessageLimit 将 msg 拆分后以机器人的名义合并转发
+func (ctx *Ctx) SendForward(msg message.Message) message.ID {
+	parts := message.Split(msg, BotConfig.MessageLimit)
+	return ctx.send(message.ForwardNodes(botNickname(), ctx.Event.SelfID, parts...))
+}
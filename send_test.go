@@ -0,0 +1,108 @@
+package zero
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestSendSplit(t *testing.T) {
+	BotConfig.MessageLimit = message.SplitOption{MaxLength: 4}
+	BotConfig.LongReply = LongReplyForward // 已拆分的消息不再转发
+	defer func() { BotConfig.MessageLimit, BotConfig.LongReply = message.SplitOption{}, LongReplyNone }()
+
+	rec := &recordCaller{}
+	ctx := &Ctx{Event: &Event{DetailType: "group", GroupID: 1, SelfID: 10}, caller: rec}
+	msg := message.NewBuilder().Linef("abc").Text("defg").Message()
+
+	ids := ctx.SendSplit(msg)
+	assert.Len(t, ids, 2)
+	if assert.Len(t, rec.requests, 2) {
+		assert.Equal(t, "send_group_msg", rec.requests[0].Action)
+		assert.Equal(t, message.Message{message.Text("abc")}, rec.requests[0].Params["message"])
+		assert.Equal(t, message.Message{message.Text("defg")}, rec.requests[1].Params["message"])
+	}
+
+	rec.requests = nil
+	ctx.SendForward(msg)
+	if assert.Len(t, rec.requests, 1) {
+		assert.Equal(t, "send_group_forward_msg", rec.requests[0].Action)
+		assert.Equal(t, message.ForwardNodes("ZeroBot", 10, message.Message{message.Text("abc")}, message.Message{message.Text("defg")}), rec.requests[0].Params["messages"])
+	}
+}
+
+// forwardFailCaller 合并转发总是失败
+type forwardFailCaller struct {
+	recordCaller
+}
+
+func (c *forwardFailCaller) CallAPI(req APIRequest) (APIResponse, error) {
+	if strings.HasSuffix(req.Action, "_forward_msg") {
+		c.requests = append(c.requests, req)
+		return APIResponse{Status: "failed", RetCode: 100}, nil
+	}
+	return c.recordCaller.CallAPI(req)
+}
+
+func TestLongReply(t *testing.T) {
+	BotConfig.MessageLimit = message.SplitOption{MaxLength: 4}
+	BotConfig.LongReply = LongReplyForward
+	defer func() {
+		BotConfig.MessageLimit = message.SplitOption{}
+		BotConfig.LongReply = LongReplyNone
+	}()
+
+	rec := &recordCaller{}
+	ctx := &Ctx{Event: &Event{DetailType: "group", GroupID: 2, SelfID: 10}, caller: rec}
+	ctx.Send("abc")
+	ctx.Send("abcdefg")
+	if assert.Len(t, rec.requests, 2) {
+		assert.Equal(t, "send_group_msg", rec.requests[0].Action)
+		assert.Equal(t, "send_group_forward_msg", rec.requests[1].Action)
+		assert.Equal(t, message.ForwardNodes("ZeroBot", 10, message.Message{message.Text("abcd")}, message.Message{message.Text("efg")}), rec.requests[1].Params["messages"])
+	}
+
+	// 群设置覆盖全局配置
+	SetGroupLongReplyPolicy(2, LongReplySplit, false)
+	defer SetGroupLongReplyPolicy(2, "", true)
+	p, ok := GroupLongReplyPolicy(2)
+	assert.True(t, ok)
+	assert.Equal(t, LongReplySplit, p)
+	rec.requests = nil
+	ctx.SendChain(message.Text("abcdefg"))
+	if assert.Len(t, rec.requests, 2) {
+		assert.Equal(t, message.Message{message.Text("abcd")}, rec.requests[0].Params["message"])
+		assert.Equal(t, message.Message{message.Text("efg")}, rec.requests[1].Params["message"])
+	}
+	SetGroupLongReplyPolicy(2, LongReplyNone, false)
+	rec.requests = nil
+	ctx.SendChain(message.Text("abcdefg"))
+	assert.Len(t, rec.requests, 1)
+
+	// 合并转发失败时拆分发送
+	fail := &forwardFailCaller{}
+	ctx = &Ctx{Event: &Event{DetailType: "private", UserID: 3}, caller: fail}
+	ctx.SendChain(message.Text("abcdefg"))
+	if assert.Len(t, fail.requests, 3) {
+		assert.Equal(t, "send_private_forward_msg", fail.requests[0].Action)
+		assert.Equal(t, "send_private_msg", fail.requests[1].Action)
+		assert.Equal(t, "send_private_msg", fail.requests[2].Action)
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	BotConfig.ValidateMessage = true
+	defer func() { BotConfig.ValidateMessage = false }()
+
+	rec := &recordCaller{}
+	ctx := &Ctx{Event: &Event{DetailType: "private", UserID: 1}, caller: rec}
+	assert.Equal(t, message.ID{}, ctx.SendChain(message.Face(1), message.Image("")))
+	assert.Empty(t, rec.requests)
+	ctx.SendChain(message.Face(1), message.Image("a.png"))
+	assert.Len(t, rec.requests, 1)
+	ctx.SendChain(message.Text(""), message.Face(1))
+	assert.Len(t, rec.requests, 2, "empty text is valid")
+}
@@ -0,0 +1,277 @@
+package zero
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Service 服务控制, 为 Engine 提供分群/分用户的启用、禁用与封禁
+//
+// 开关以 id 区分: 群为群号, 私聊为 -QQ号, 见 ServiceTargetID
+type Service struct {
+	mu               sync.RWMutex
+	name             string
+	disableOnDefault bool
+	switches         map[int64]bool
+	bans             map[Ban]struct{}
+}
+
+// Ban 封禁记录, GroupID 为 0 时在所有群及私聊中封禁
+type Ban struct {
+	UserID  int64 `json:"user_id"`
+	GroupID int64 `json:"group_id"`
+}
+
+var (
+	services   = map[string]*Service{}
+	servicesMu sync.RWMutex
+)
+
+func init() {
+	RegisterPermissionRule((&Service{}).Handler)
+	onStorageChange = append(onStorageChange, func() {
+		RangeServices(func(_ string, s *Service) bool {
+			s.load()
+			return true
+		})
+	})
+}
+
+// UseService 将 Engine 注册为名为 name 的服务, 并添加服务控制 PreHandler
+//
+// 重复注册同名服务将 panic
+func (e *Engine) UseService(name string, disableOnDefault bool) *Engine {
+	s := &Service{
+		name:             name,
+		disableOnDefault: disableOnDefault,
+	}
+	s.load()
+	servicesMu.Lock()
+	if _, ok := services[name]; ok {
+		servicesMu.Unlock()
+		panic("zero: service " + name + " already exists")
+	}
+	services[name] = s
+	servicesMu.Unlock()
+	e.name = name
+	e.service = s
+	e.preHandler = append([]Rule{s.Handler}, e.preHandler...)
+	return e
+}
+
+// Service 返回 Engine 的服务控制, 未调用 UseService 时返回 nil
+func (e *Engine) Service() *Service {
+	return e.service
+}
+
+// LookupService 按名称查找服务
+func LookupService(name string) (*Service, bool) {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	s, ok := services[name]
+	return s, ok
+}
+
+// RangeServices 按名称顺序遍历所有服务
+//
+// 单次操作返回 true 则继续遍历，否则退出
+func RangeServices(iter func(name string, s *Service) bool) {
+	servicesMu.RLock()
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	servicesMu.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		s, ok := LookupService(name)
+		if ok && !iter(name, s) {
+			return
+		}
+	}
+}
+
+// ServiceTargetID 返回 ctx 对应的开关 id: 群为群号, 私聊为 -QQ号
+func ServiceTargetID(ctx *Ctx) int64 {
+	if ctx.Event.GroupID != 0 {
+		return ctx.Event.GroupID
+	}
+	return -ctx.Event.UserID
+}
+
+// Name 服务名称
+func (s *Service) Name() string {
+	return s.name
+}
+
+// DisableOnDefault 是否默认禁用
+func (s *Service) DisableOnDefault() bool {
+	return s.disableOnDefault
+}
+
+// Handler 服务控制 Rule, 已由 UseService 添加为 PreHandler
+func (s *Service) Handler(ctx *Ctx) bool {
+	if ctx.Event == nil || ctx.Event.GroupID == 0 && ctx.Event.UserID == 0 {
+		return true // 元事件等不受控制
+	}
+	if ctx.Event.UserID != 0 && s.IsBanned(ctx.Event.UserID, ctx.Event.GroupID) {
+		return false
+	}
+	return s.IsEnabledIn(ServiceTargetID(ctx))
+}
+
+// IsEnabledIn 服务是否在 id 中启用
+func (s *Service) IsEnabledIn(id int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st, ok := s.switches[id]; ok {
+		return st
+	}
+	return !s.disableOnDefault
+}
+
+// Enable 在 id 中启用服务
+func (s *Service) Enable(id int64) {
+	s.setSwitch(id, true)
+}
+
+// Disable 在 id 中禁用服务
+func (s *Service) Disable(id int64) {
+	s.setSwitch(id, false)
+}
+
+// Reset 将 id 的开关恢复为默认状态
+func (s *Service) Reset(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.switches, id)
+	s.delete(s.switchKey(id))
+}
+
+// Switches 返回所有非默认状态的开关
+func (s *Service) Switches() map[int64]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m := make(map[int64]bool, len(s.switches))
+	for k, v := range s.switches {
+		m[k] = v
+	}
+	return m
+}
+
+// Ban 在群 groupID 中封禁 userID, groupID 为 0 时全局封禁
+func (s *Service) Ban(userID, groupID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := Ban{UserID: userID, GroupID: groupID}
+	s.bans[b] = struct{}{}
+	s.put(s.banKey(b), []byte{1})
+}
+
+// Unban 解除 userID 在群 groupID 中的封禁
+func (s *Service) Unban(userID, groupID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := Ban{UserID: userID, GroupID: groupID}
+	delete(s.bans, b)
+	s.delete(s.banKey(b))
+}
+
+// IsBanned userID 在群 groupID 中是否被封禁 (含全局封禁)
+func (s *Service) IsBanned(userID, groupID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.bans[Ban{UserID: userID}]; ok {
+		return true
+	}
+	_, ok := s.bans[Ban{UserID: userID, GroupID: groupID}]
+	return ok
+}
+
+// Bans 返回所有封禁记录
+func (s *Service) Bans() []Ban {
+	s.mu.RLock()
+	bans := make([]Ban, 0, len(s.bans))
+	for b := range s.bans {
+		bans = append(bans, b)
+	}
+	s.mu.RUnlock()
+	sort.Slice(bans, func(i, j int) bool {
+		if bans[i].GroupID != bans[j].GroupID {
+			return bans[i].GroupID < bans[j].GroupID
+		}
+		return bans[i].UserID < bans[j].UserID
+	})
+	return bans
+}
+
+func (s *Service) setSwitch(id int64, enable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.switches[id] = enable
+	v := []byte{0}
+	if enable {
+		v[0] = 1
+	}
+	s.put(s.switchKey(id), v)
+}
+
+// 存储格式:
+//
+//	service\x00{name}\x00s{id}       -> 0/1
+//	service\x00{name}\x00b{uid}{gid} -> 1
+func (s *Service) prefix() []byte {
+	return append(append([]byte("service\x00"), s.name...), 0)
+}
+
+func (s *Service) switchKey(id int64) []byte {
+	k := append(s.prefix(), 's')
+	return binary.BigEndian.AppendUint64(k, uint64(id))
+}
+
+func (s *Service) banKey(b Ban) []byte {
+	k := append(s.prefix(), 'b')
+	k = binary.BigEndian.AppendUint64(k, uint64(b.UserID))
+	return binary.BigEndian.AppendUint64(k, uint64(b.GroupID))
+}
+
+func (s *Service) put(k, v []byte) {
+	if err := getStorage().Put(k, v); err != nil {
+		log.Warnln("[service] 保存服务", s.name, "状态时出现错误:", err)
+	}
+}
+
+func (s *Service) delete(k []byte) {
+	if err := getStorage().Delete(k); err != nil {
+		log.Warnln("[service] 保存服务", s.name, "状态时出现错误:", err)
+	}
+}
+
+// load 从存储中载入开关与封禁记录
+func (s *Service) load() {
+	switches, bans := map[int64]bool{}, map[Ban]struct{}{}
+	prefix := s.prefix()
+	getStorage().Iterator(func(k, v []byte) bool {
+		if !bytes.HasPrefix(k, prefix) {
+			return true
+		}
+		k = k[len(prefix):]
+		switch {
+		case len(k) == 9 && k[0] == 's' && len(v) == 1:
+			switches[int64(binary.BigEndian.Uint64(k[1:]))] = v[0] != 0
+		case len(k) == 17 && k[0] == 'b':
+			bans[Ban{
+				UserID:  int64(binary.BigEndian.Uint64(k[1:])),
+				GroupID: int64(binary.BigEndian.Uint64(k[9:])),
+			}] = struct{}{}
+		}
+		return true
+	})
+	s.mu.Lock()
+	s.switches, s.bans = switches, bans
+	s.mu.Unlock()
+}
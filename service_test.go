@@ -0,0 +1,48 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService(t *testing.T) {
+	mem := &memoryStorage{m: map[string][]byte{}}
+	SetStorage(mem)
+	defer SetStorage(&memoryStorage{m: map[string][]byte{}})
+
+	e := New().UseService("service_test", true)
+	assert.Equal(t, "service_test", e.Name())
+	assert.Panics(t, func() { New().UseService("service_test", false) })
+	s, ok := LookupService("service_test")
+	assert.True(t, ok)
+	assert.Same(t, e.Service(), s)
+
+	group := &Ctx{Event: &Event{GroupID: 100, UserID: 1}}
+	private := &Ctx{Event: &Event{UserID: 1}}
+	assert.Equal(t, int64(-1), ServiceTargetID(private))
+	assert.False(t, s.Handler(group)) // 默认禁用
+	s.Enable(100)
+	assert.True(t, s.Handler(group))
+	assert.False(t, s.Handler(private))
+
+	s.Ban(1, 100)
+	assert.False(t, s.Handler(group))
+	s.Enable(-1)
+	assert.True(t, s.Handler(private))
+	s.Ban(1, 0)
+	assert.False(t, s.Handler(private))
+	s.Unban(1, 0)
+	assert.Equal(t, []Ban{{UserID: 1, GroupID: 100}}, s.Bans())
+
+	// 重新载入后状态不变
+	cfg := BotConfig
+	defer func() { BotConfig = cfg }()
+	runinit(&Config{Storage: mem})
+	assert.Same(t, mem, getStorage())
+	assert.Equal(t, map[int64]bool{100: true, -1: true}, s.Switches())
+	assert.True(t, s.IsBanned(1, 100))
+	assert.False(t, s.IsBanned(1, 101))
+	s.Reset(100)
+	assert.False(t, s.IsEnabledIn(100))
+}
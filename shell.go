@@ -1,9 +1,12 @@
 package zero
 
 import (
+	"errors"
 	"flag"
 	"reflect"
 	"strings"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
 )
 
 func isSpace(r rune) bool {
@@ -98,56 +101,165 @@ func ParseShell(s string) []string {
 }
 
 // ShellRule 定义shell-like规则
+//
+// model 中带有 flag 或 arg tag 的字段会被绑定, 可用的 tag 见 shellField
 func ShellRule(cmd string, model interface{}) Rule {
+	return shellRule(cmd, model, false)
+}
+
+// ShellRuleWithUsage 与 ShellRule 相同, 但在参数错误或使用 -h 时
+// 回复用法说明与错误信息, 并阻止后续 Matcher 匹配
+func ShellRuleWithUsage(cmd string, model interface{}) Rule {
+	return shellRule(cmd, model, true)
+}
+
+func shellRule(cmd string, model interface{}, withUsage bool) Rule {
 	cmdRule := CommandRule(cmd)
 	t := reflect.TypeOf(model)
+	sm := newShellModel(t)
 	return func(ctx *Ctx) bool {
 		if !cmdRule(ctx) {
 			return false
 		}
 		// bind flag to struct
-		args := ParseShell(ctx.State["args"].(string))
+		args, segs := shellArgs(ctx)
 		val := reflect.New(t)
-		fs := registerFlag(t, val)
-		err := fs.Parse(args)
+		rest, err := sm.bind(val.Elem(), args, segs)
 		if err != nil {
+			if withUsage {
+				reply := sm.usage(ctx.commandPrefix() + cmd)
+				if !errors.Is(err, flag.ErrHelp) {
+					reply = "错误: " + shellError(err) + "\n" + reply
+				}
+				ctx.SendChain(message.Text(reply))
+				ctx.Break()
+			}
 			return false
 		}
-		ctx.State["args"] = fs.Args()
+		ctx.State["args"] = rest
 		ctx.State["flag"] = val.Interface()
 		return true
 	}
 }
 
+// shellError 将 flag 包的错误转换为中文提示
+func shellError(err error) string {
+	msg := err.Error()
+	for _, r := range [...][2]string{
+		{"flag provided but not defined: ", "未知选项 "},
+		{"flag needs an argument: ", "选项缺少参数 "},
+		{"bad flag syntax: ", "选项格式错误 "},
+	} {
+		if strings.HasPrefix(msg, r[0]) {
+			return r[1] + msg[len(r[0]):]
+		}
+	}
+	return msg
+}
+
 var (
 	boolType    = reflect.TypeOf(false)
-	intType     = reflect.TypeOf(0)
-	stringType  = reflect.TypeOf("")
 	float64Type = reflect.TypeOf(float64(0))
 )
 
 func registerFlag(t reflect.Type, v reflect.Value) *flag.FlagSet {
-	v = v.Elem()
-	fs := flag.NewFlagSet("", flag.ContinueOnError)
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		name := field.Tag.Get("flag")
-		if name == "" {
+	fs, _ := newShellModel(t).flagSet(v.Elem(), nil)
+	return fs
+}
+
+// ShellUsage 根据 model 的 flag 与 help tag 生成用法说明
+func ShellUsage(cmd string, model interface{}) string {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return shellUsage(defaultCommandPrefix()+cmd, t)
+}
+
+func shellUsage(cmd string, t reflect.Type) string {
+	return newShellModel(t).usage(cmd)
+}
+
+// usage 生成用法说明, cmd 须带有命令前缀
+func (sm *shellModel) usage(cmd string) string {
+	sb := strings.Builder{}
+	sb.WriteString("用法: ")
+	sb.WriteString(cmd)
+	lines := make([][2]string, 0, len(sm.fields))
+	width := 0
+	hasFlag := false
+	for i := range sm.fields {
+		f := &sm.fields[i]
+		ft := sm.t.Field(f.index).Type
+		var left string
+		if f.positional() {
+			left = f.name
+		} else {
+			hasFlag = true
+			left = "-" + strings.Join(f.names, ", -")
+			if ft != boolType {
+				left += " " + typeName(ft)
+			}
+		}
+		help := f.help
+		if len(f.enum) > 0 {
+			help += " (可选: " + strings.Join(f.enum, "|") + ")"
+		}
+		if f.def != "" {
+			help += " (默认: " + f.def + ")"
+		}
+		if f.required {
+			help += " (必填)"
+		}
+		help = strings.TrimSpace(help)
+		if len(left) > width {
+			width = len(left)
+		}
+		lines = append(lines, [2]string{left, help})
+	}
+	if hasFlag {
+		sb.WriteString(" [选项]")
+	}
+	for i := range sm.fields {
+		f := &sm.fields[i]
+		if !f.positional() {
 			continue
 		}
-		help := field.Tag.Get("help")
-		switch field.Type {
-		case boolType:
-			fs.BoolVar(v.Field(i).Addr().Interface().(*bool), name, false, help)
-		case intType:
-			fs.IntVar(v.Field(i).Addr().Interface().(*int), name, 0, help)
-		case stringType:
-			fs.StringVar(v.Field(i).Addr().Interface().(*string), name, "", help)
-		case float64Type:
-			fs.Float64Var(v.Field(i).Addr().Interface().(*float64), name, 0, help)
-		default:
-			panic("unsupported type")
+		name := f.name
+		if k := sm.t.Field(f.index).Type.Kind(); k == reflect.Slice {
+			name += "..."
+		}
+		if f.required {
+			sb.WriteString(" <" + name + ">")
+		} else {
+			sb.WriteString(" [" + name + "]")
 		}
 	}
-	return fs
+	for _, l := range lines {
+		sb.WriteString("\n  ")
+		sb.WriteString(l[0])
+		if l[1] != "" {
+			sb.WriteString(strings.Repeat(" ", width-len(l[0])+2))
+			sb.WriteString(l[1])
+		}
+	}
+	return sb.String()
+}
+
+// typeName 返回用于用法说明的类型名
+func typeName(t reflect.Type) string {
+	switch {
+	case t == float64Type:
+		return "float"
+	case t == durationType:
+		return "duration"
+	case t == segmentType:
+		return "segment"
+	case isFlagValue(t):
+		return "value"
+	case t.Kind() == reflect.Slice:
+		return typeName(t.Elem()) + "..."
+	default:
+		return t.String()
+	}
 }
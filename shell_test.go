@@ -4,8 +4,11 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
 )
 
 func Test_parse(t *testing.T) {
@@ -43,3 +46,115 @@ func Test_registerFlag(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, got)
 }
+
+type level int
+
+func (l *level) String() string { return strconv.Itoa(int(*l)) }
+
+func (l *level) Set(s string) error {
+	*l = level(len(s))
+	return nil
+}
+
+func TestShellRule(t *testing.T) {
+	type model struct {
+		Timeout time.Duration   `flag:"t,timeout" default:"3s"`
+		Tags    []string        `flag:"tag"`
+		Count   uint            `flag:"n"`
+		ID      int64           `flag:"id"`
+		Mode    string          `flag:"m" enum:"a,b" default:"a"`
+		Level   level           `flag:"v"`
+		Target  int64           `arg:"0" required:"true"`
+		Pic     message.Segment `arg:"1"`
+		Rest    []string        `arg:"2"`
+	}
+	rule := ShellRule("test", model{})
+	run := func(msg ...message.Segment) (*model, []string, bool) {
+		ctx := &Ctx{Event: &Event{Message: msg}, State: State{}}
+		if !rule(ctx) {
+			return nil, nil, false
+		}
+		return ctx.State["flag"].(*model), ctx.State["args"].([]string), true
+	}
+
+	m, args, ok := run(
+		message.Text("test -timeout 1m -tag a -tag b -n 2 -id 10 -m b -v vvv "),
+		message.At(123),
+		message.Image("x.png"),
+		message.Text(" c d"),
+	)
+	assert.True(t, ok)
+	assert.Equal(t, model{
+		Timeout: time.Minute,
+		Tags:    []string{"a", "b"},
+		Count:   2,
+		ID:      10,
+		Mode:    "b",
+		Level:   3,
+		Target:  123,
+		Pic:     message.Image("x.png"),
+		Rest:    []string{"c", "d"},
+	}, *m)
+	assert.Equal(t, []string{"123", "x.png", "c", "d"}, args)
+
+	m, _, ok = run(message.Text("test 1"))
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, m.Timeout)
+	assert.Equal(t, "a", m.Mode)
+
+	_, _, ok = run(message.Text("test"))
+	assert.False(t, ok, "missing required argument")
+	_, _, ok = run(message.Text("test -m c 1"))
+	assert.False(t, ok, "value not in enum")
+	_, _, ok = run(message.Text("test -n -1 1"))
+	assert.False(t, ok, "negative uint")
+
+	type simple struct {
+		N    int    `flag:"n" default:"3" required:"true"`
+		Name string `arg:"0"`
+	}
+	ctx := &Ctx{Event: &Event{Message: message.Message{message.Text(`x "a `), message.At(2), message.Text(" b")}}, State: State{}}
+	assert.True(t, ShellRule("x", simple{})(ctx), "default satisfies required")
+	assert.Equal(t, &simple{N: 3, Name: "a  2  b"}, ctx.State["flag"])
+	assert.Equal(t, []string{"a  2  b"}, ctx.State["args"], "placeholder restored in unclosed quote")
+
+	assert.Equal(t, "用法: test [选项] <target> [pic] [rest...]\n"+
+		"  -t, -timeout duration  (默认: 3s)\n"+
+		"  -tag string...\n"+
+		"  -n uint\n"+
+		"  -id int64\n"+
+		"  -m string              (可选: a|b) (默认: a)\n"+
+		"  -v value\n"+
+		"  target                 (必填)\n"+
+		"  pic\n"+
+		"  rest", ShellUsage("test", model{}))
+}
+
+func TestShellRuleWithUsage(t *testing.T) {
+	type model struct {
+		N    int    `flag:"n" help:"次数"`
+		Host string `arg:"0" required:"true"`
+	}
+	e := New()
+	defer e.Delete()
+	m := e.OnShellWithUsage("ping", model{}).Handle(func(*Ctx) {})
+
+	ctx, rec, ok := checkRules(m, "ping -h")
+	assert.False(t, ok)
+	assert.True(t, ctx.ma.Break)
+	assert.False(t, m.Break, "registered matcher untouched")
+	assert.Equal(t, "用法: ping [选项] <host>\n  -n int  次数\n  host    (必填)", rec.sent())
+
+	ctx, rec, _ = checkRules(m, "ping -x 1")
+	assert.True(t, ctx.ma.Break)
+	assert.Equal(t, "错误: 未知选项 -x\n用法: ping [选项] <host>\n  -n int  次数\n  host    (必填)", rec.sent())
+
+	_, rec, _ = checkRules(m, "ping -n 3")
+	assert.Contains(t, rec.sent(), "错误: 缺少参数 host")
+
+	ctx, rec, ok = checkRules(m, "ping -n 3 example.com")
+	assert.True(t, ok)
+	assert.False(t, ctx.ma.Break)
+	assert.Empty(t, rec.requests)
+	assert.Equal(t, &model{N: 3, Host: "example.com"}, ctx.State["flag"])
+}
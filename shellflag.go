@@ -0,0 +1,307 @@
+package zero
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+var (
+	flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	segmentType   = reflect.TypeOf(message.Segment{})
+)
+
+// shellSegMark 非文本消息段在参数中的占位符标记
+const shellSegMark = "\x1a"
+
+// shellField 绑定到 shell 参数的字段
+//
+// 支持的 tag:
+//
+//	flag:"t,timeout" 选项名, 以逗号分隔短/长名称
+//	arg:"0"          位置参数序号, 切片字段接收其后所有参数
+//	help:"..."       用法说明
+//	default:"..."    默认值, 切片以逗号分隔
+//	required:"true"  必填, 设置了 default 时省略也视为已填写
+//	enum:"a,b,c"     可选值
+type shellField struct {
+	index    int
+	name     string
+	names    []string
+	arg      int
+	help     string
+	def      string
+	required bool
+	enum     []string
+}
+
+func (f *shellField) positional() bool {
+	return f.arg >= 0
+}
+
+// display 返回用于提示的字段名
+func (f *shellField) display() string {
+	if f.positional() {
+		return f.name
+	}
+	return "-" + f.names[0]
+}
+
+// shellModel 由结构体类型解析出的 shell 参数定义
+type shellModel struct {
+	t      reflect.Type
+	fields []shellField
+}
+
+func newShellModel(t reflect.Type) *shellModel {
+	sm := &shellModel{t: t}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		f := shellField{
+			index:    i,
+			name:     strings.ToLower(field.Name),
+			arg:      -1,
+			help:     field.Tag.Get("help"),
+			def:      field.Tag.Get("default"),
+			required: field.Tag.Get("required") == "true",
+		}
+		if name := field.Tag.Get("flag"); name != "" {
+			f.names = strings.Split(name, ",")
+		} else if arg, ok := field.Tag.Lookup("arg"); ok {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				panic("zero: invalid arg tag " + strconv.Quote(arg) + " of field " + field.Name)
+			}
+			f.arg = n
+		} else {
+			continue
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			f.enum = strings.Split(enum, ",")
+		}
+		if !isFlagValue(field.Type) && field.Type != segmentType && !settable(field.Type) {
+			panic("zero: unsupported type " + field.Type.String() + " of field " + field.Name)
+		}
+		sm.fields = append(sm.fields, f)
+	}
+	return sm
+}
+
+func isFlagValue(t reflect.Type) bool {
+	return t.Implements(flagValueType) && t.Kind() == reflect.Ptr || reflect.PointerTo(t).Implements(flagValueType)
+}
+
+// shellValue 实现 flag.Value, 负责消息段替换与枚举校验
+type shellValue struct {
+	v    reflect.Value
+	f    *shellField
+	segs []message.Segment
+	set  bool
+}
+
+func (sv *shellValue) String() string {
+	if sv == nil || !sv.v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(sv.v.Interface())
+}
+
+func (sv *shellValue) IsBoolFlag() bool {
+	if sv.v.Kind() == reflect.Bool {
+		return true
+	}
+	if fv, ok := sv.flagValue(); ok {
+		b, ok := fv.(interface{ IsBoolFlag() bool })
+		return ok && b.IsBoolFlag()
+	}
+	return false
+}
+
+func (sv *shellValue) flagValue() (flag.Value, bool) {
+	if sv.v.Kind() == reflect.Ptr && sv.v.Type().Implements(flagValueType) {
+		if sv.v.IsNil() {
+			sv.v.Set(reflect.New(sv.v.Type().Elem()))
+		}
+		return sv.v.Interface().(flag.Value), true
+	}
+	fv, ok := sv.v.Addr().Interface().(flag.Value)
+	return fv, ok
+}
+
+func (sv *shellValue) Set(s string) error {
+	if !sv.set && sv.v.Kind() == reflect.Slice {
+		sv.v.Set(reflect.Zero(sv.v.Type())) // 覆盖默认值
+	}
+	sv.set = true
+	seg, isSeg := segmentOf(s, sv.segs)
+	if isSeg && sv.v.Type() == segmentType {
+		sv.v.Set(reflect.ValueOf(seg))
+		return nil
+	}
+	if isSeg {
+		s = segmentText(seg)
+	} else {
+		s = restoreSegments(s, sv.segs)
+	}
+	if len(sv.f.enum) > 0 && !contains(sv.f.enum, s) {
+		return errors.New("可选值为 " + strings.Join(sv.f.enum, ", "))
+	}
+	if fv, ok := sv.flagValue(); ok {
+		return fv.Set(s)
+	}
+	return setString(sv.v, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentOf 解析占位符对应的消息段
+func segmentOf(s string, segs []message.Segment) (message.Segment, bool) {
+	if len(s) < 3 || !strings.HasPrefix(s, shellSegMark) || !strings.HasSuffix(s, shellSegMark) {
+		return message.Segment{}, false
+	}
+	i, err := strconv.Atoi(s[1 : len(s)-1])
+	if err != nil || i < 0 || i >= len(segs) {
+		return message.Segment{}, false
+	}
+	return segs[i], true
+}
+
+var shellSegRegexp = regexp.MustCompile(shellSegMark + `(\d+)` + shellSegMark)
+
+// restoreSegments 将 s 中夹在其它文本里的占位符 (如引号未闭合时) 还原为消息段的文本
+func restoreSegments(s string, segs []message.Segment) string {
+	if !strings.Contains(s, shellSegMark) {
+		return s
+	}
+	return shellSegRegexp.ReplaceAllStringFunc(s, func(mark string) string {
+		if seg, ok := segmentOf(mark, segs); ok {
+			return segmentText(seg)
+		}
+		return mark
+	})
+}
+
+// segmentText 消息段作为文本参数时的值: at 为 QQ 号, 图片等为 url
+func segmentText(seg message.Segment) string {
+	switch seg.Type {
+	case "text":
+		return seg.Data["text"]
+	case "at":
+		return seg.Data["qq"]
+	case "reply":
+		return seg.Data["id"]
+	case "image", "record", "video":
+		if u := seg.Data["url"]; u != "" {
+			return u
+		}
+		return seg.Data["file"]
+	default:
+		return seg.String()
+	}
+}
+
+// shellArgs 切分 ctx 中命令后的参数, 非文本消息段以占位符代替
+func shellArgs(ctx *Ctx) ([]string, []message.Segment) {
+	plain, _ := ctx.State["args"].(string)
+	sb := strings.Builder{}
+	var segs []message.Segment
+	for _, seg := range ctx.Event.Message {
+		switch seg.Type {
+		case "text":
+			sb.WriteString(seg.Data["text"])
+		case "reply":
+		default:
+			sb.WriteString(" " + shellSegMark + strconv.Itoa(len(segs)) + shellSegMark + " ")
+			segs = append(segs, seg)
+		}
+	}
+	if len(segs) == 0 {
+		return ParseShell(plain), nil
+	}
+	rest, ok := ctx.trimCommandPrefix(strings.TrimSpace(sb.String()))
+	command, _ := ctx.State["command"].(string)
+	if !ok || !strings.HasPrefix(rest, command) {
+		return ParseShell(plain), nil
+	}
+	return ParseShell(rest[len(command):]), segs
+}
+
+// flagSet 创建绑定到 v 的 FlagSet, 并写入默认值
+func (sm *shellModel) flagSet(v reflect.Value, segs []message.Segment) (*flag.FlagSet, []*shellValue) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	values := make([]*shellValue, len(sm.fields))
+	for i := range sm.fields {
+		f := &sm.fields[i]
+		sv := &shellValue{v: v.Field(f.index), f: f, segs: segs}
+		if f.def != "" {
+			defs := []string{f.def}
+			if sv.v.Kind() == reflect.Slice && !isFlagValue(sv.v.Type()) {
+				defs = strings.Split(f.def, ",")
+			}
+			for _, d := range defs {
+				if err := sv.Set(d); err != nil {
+					panic("zero: invalid default " + strconv.Quote(f.def) + " of " + f.display() + ": " + err.Error())
+				}
+			}
+			sv.set = false
+		}
+		values[i] = sv
+		for _, name := range f.names {
+			fs.Var(sv, name, f.help)
+		}
+	}
+	return fs, values
+}
+
+// bind 解析 args 并写入 v, 返回所有位置参数
+func (sm *shellModel) bind(v reflect.Value, args []string, segs []message.Segment) ([]string, error) {
+	fs, values := sm.flagSet(v, segs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	rest := fs.Args()
+	for _, sv := range values {
+		f := sv.f
+		if f.positional() && f.arg < len(rest) {
+			end := f.arg + 1
+			if sv.v.Kind() == reflect.Slice && !isFlagValue(sv.v.Type()) {
+				end = len(rest)
+			}
+			for _, a := range rest[f.arg:end] {
+				if err := sv.Set(a); err != nil {
+					return nil, fmt.Errorf("参数 %s 的值 %q 无效: %w", f.display(), a, err)
+				}
+			}
+		}
+		if f.required && !sv.set && f.def == "" { // 默认值视为已填写
+			if f.positional() {
+				return nil, errors.New("缺少参数 " + f.display())
+			}
+			return nil, errors.New("缺少选项 " + f.display())
+		}
+	}
+	for i, a := range rest {
+		if seg, ok := segmentOf(a, segs); ok {
+			rest[i] = segmentText(seg)
+		} else {
+			rest[i] = restoreSegments(a, segs)
+		}
+	}
+	return rest, nil
+}
@@ -0,0 +1,77 @@
+package zero
+
+import (
+	"sort"
+)
+
+// editDistance 计算 a, b 按 rune 的编辑距离,
+// 相邻字符交换计为一次编辑 (optimal string alignment)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	pprev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if d := pprev[j-2] + 1; d < cur[j] {
+					cur[j] = d
+				}
+			}
+		}
+		pprev, prev, cur = prev, cur, pprev
+	}
+	return prev[len(rb)]
+}
+
+// similarity 返回 a, b 的相似度, 范围 [0, 1]
+func similarity(a, b string) float64 {
+	la, lb := len([]rune(a)), len([]rune(b))
+	if la < lb {
+		la = lb
+	}
+	if la == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(la)
+}
+
+// suggest 按相似度从高到低返回 candidates 中与 input 相似度不低于 threshold 的项
+func suggest(input string, candidates []string, threshold float64) []string {
+	type scored struct {
+		s     string
+		score float64
+	}
+	list := make([]scored, 0, len(candidates))
+	seen := make(map[string]struct{}, len(candidates))
+	for _, c := range candidates {
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		if score := similarity(input, c); score >= threshold {
+			list = append(list, scored{s: c, score: score})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score > list[j].score })
+	res := make([]string, len(list))
+	for i, c := range list {
+		res[i] = c.s
+	}
+	return res
+}
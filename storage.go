@@ -0,0 +1,92 @@
+package zero
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Storage 持久化存储接口, extension/kv 的 Bucket 即满足此接口
+type Storage interface {
+	Get(k []byte) ([]byte, error)
+	Put(k []byte, v []byte) error
+	Delete(k []byte) error
+	Iterator(func(k, v []byte) bool)
+}
+
+var errStorageNotFound = errors.New("zero: key not found")
+
+var (
+	storage   Storage = &memoryStorage{m: map[string][]byte{}}
+	storageMu sync.RWMutex
+	// onStorageChange 在 SetStorage 后调用, 用于重新载入数据
+	onStorageChange []func()
+)
+
+// SetStorage 设置服务开关、群命令前缀等数据的持久化存储 (默认仅保存在内存)
+//
+// 一般通过 Config.Storage 设置, 需要在 Run 之前载入数据时可直接调用
+func SetStorage(s Storage) {
+	storageMu.Lock()
+	storage = s
+	hooks := make([]func(), len(onStorageChange))
+	copy(hooks, onStorageChange)
+	storageMu.Unlock()
+	for _, f := range hooks {
+		f()
+	}
+}
+
+func getStorage() Storage {
+	storageMu.RLock()
+	defer storageMu.RUnlock()
+	return storage
+}
+
+// memoryStorage 内存存储
+type memoryStorage struct {
+	sync.RWMutex
+	m map[string][]byte
+}
+
+func (s *memoryStorage) Get(k []byte) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+	v, ok := s.m[string(k)]
+	if !ok {
+		return nil, errStorageNotFound
+	}
+	return v, nil
+}
+
+func (s *memoryStorage) Put(k []byte, v []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.m[string(k)] = append([]byte(nil), v...)
+	return nil
+}
+
+func (s *memoryStorage) Delete(k []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.m, string(k))
+	return nil
+}
+
+func (s *memoryStorage) Iterator(iter func(k, v []byte) bool) {
+	s.RLock()
+	keys := make([]string, 0, len(s.m))
+	for k := range s.m {
+		keys = append(keys, k)
+	}
+	s.RUnlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.RLock()
+		v, ok := s.m[k]
+		s.RUnlock()
+		if ok && !iter([]byte(k), v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package zero
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FloatTech/ttl"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// 追踪记录保存 5 分钟
+var eventTraces = ttl.NewCache[int64, *EventTrace](time.Minute * 5)
+
+// EventTrace 单个事件的匹配过程记录
+//
+// 仅在 Config.Trace 开启时记录, GetEventTrace 与 Ctx.Trace 返回的是调用时的快照
+type EventTrace struct {
+	mu         sync.Mutex
+	MessageID  message.ID      `json:"message_id"`
+	Time       time.Time       `json:"time"`
+	PostType   string          `json:"post_type"`
+	DetailType string          `json:"detail_type"`
+	Matchers   []*MatcherTrace `json:"matchers"`
+	BlockedBy  string          `json:"blocked_by,omitempty"` // 阻断后续匹配的 Matcher
+}
+
+// MatcherTrace 单个 Matcher 的匹配过程记录
+type MatcherTrace struct {
+	tr        *EventTrace
+	Name      string      `json:"name"`
+	Engine    string      `json:"engine"`
+	Priority  int         `json:"priority"`
+	Steps     []RuleTrace `json:"steps"`
+	Triggered bool        `json:"triggered"`            // Handler 是否被调用
+	StoppedAt string      `json:"stopped_at,omitempty"` // 未触发时停在的阶段
+	Block     bool        `json:"block"`                // 是否阻断后续 Matcher
+	Break     bool        `json:"break"`                // 是否因 Break 退出匹配流程
+}
+
+// RuleTrace 单条 Rule 的执行记录
+type RuleTrace struct {
+	Stage    string        `json:"stage"` // pre, rule, mid
+	Index    int           `json:"index"`
+	Rule     string        `json:"rule"`
+	Passed   bool          `json:"passed"`
+	Timeout  bool          `json:"timeout"`
+	Duration time.Duration `json:"duration"`
+}
+
+// GetEventTrace 获取消息 id 对应事件的匹配过程记录的快照, 不存在时返回 nil
+func GetEventTrace(id message.ID) *EventTrace {
+	return eventTraces.Get(id.ID()).snapshot()
+}
+
+// Trace 获取当前事件的匹配过程记录的快照, 未开启 Config.Trace 时返回 nil
+func (ctx *Ctx) Trace() *EventTrace {
+	return ctx.trace.snapshot()
+}
+
+// snapshot 在 mu 下复制记录, 以免与仍在进行的匹配竞争
+func (tr *EventTrace) snapshot() *EventTrace {
+	if tr == nil {
+		return nil
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	cp := &EventTrace{
+		MessageID:  tr.MessageID,
+		Time:       tr.Time,
+		PostType:   tr.PostType,
+		DetailType: tr.DetailType,
+		Matchers:   make([]*MatcherTrace, len(tr.Matchers)),
+		BlockedBy:  tr.BlockedBy,
+	}
+	for i, mt := range tr.Matchers {
+		m := *mt
+		m.tr = cp
+		m.Steps = append([]RuleTrace(nil), mt.Steps...)
+		cp.Matchers[i] = &m
+	}
+	return cp
+}
+
+func newEventTrace(e *Event, id message.ID) *EventTrace {
+	tr := &EventTrace{
+		MessageID:  id,
+		Time:       time.Now(),
+		PostType:   e.PostType,
+		DetailType: e.DetailType,
+	}
+	if id.String() != "" {
+		eventTraces.Set(id.ID(), tr)
+	}
+	return tr
+}
+
+// begin 开始记录一个 Matcher, tr 为 nil 时不记录
+func (tr *EventTrace) begin(m *Matcher) *MatcherTrace {
+	if tr == nil {
+		return nil
+	}
+	engine, name := matcherLabels(m)
+	mt := &MatcherTrace{tr: tr, Name: name, Engine: engine, Priority: m.Priority}
+	tr.mu.Lock()
+	tr.Matchers = append(tr.Matchers, mt)
+	tr.mu.Unlock()
+	return mt
+}
+
+// step 记录一条 Rule 的执行结果
+func (mt *MatcherTrace) step(stage string, i int, r Rule, passed, timeout bool, start time.Time) {
+	if mt == nil {
+		return
+	}
+	mt.tr.mu.Lock()
+	defer mt.tr.mu.Unlock()
+	mt.Steps = append(mt.Steps, RuleTrace{
+		Stage:    stage,
+		Index:    i,
+		Rule:     ruleName(r),
+		Passed:   passed,
+		Timeout:  timeout,
+		Duration: time.Since(start),
+	})
+	if !passed {
+		mt.StoppedAt = stage + "[" + strconv.Itoa(i) + "]"
+	}
+}
+
+// finish 记录 Matcher 的最终状态
+func (mt *MatcherTrace) finish(m *Matcher, triggered bool) {
+	if mt == nil {
+		return
+	}
+	mt.tr.mu.Lock()
+	defer mt.tr.mu.Unlock()
+	mt.Triggered = triggered
+	mt.Block = m.Block
+	mt.Break = !triggered && m.Break
+	if triggered && m.Block {
+		mt.tr.BlockedBy = mt.label()
+	}
+}
+
+func (mt *MatcherTrace) label() string {
+	name := mt.Name
+	if name == "" {
+		name = "<unnamed>"
+	}
+	if mt.Engine != "" {
+		name = mt.Engine + "/" + name
+	}
+	return name
+}
+
+// String 以便于阅读的形式输出记录
+func (tr *EventTrace) String() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	sb := strings.Builder{}
+	sb.WriteString("事件 ")
+	sb.WriteString(tr.PostType)
+	if tr.DetailType != "" {
+		sb.WriteByte('/')
+		sb.WriteString(tr.DetailType)
+	}
+	if tr.MessageID.String() != "" {
+		sb.WriteString(" (id=")
+		sb.WriteString(tr.MessageID.String())
+		sb.WriteByte(')')
+	}
+	sb.WriteString(" 共检查 ")
+	sb.WriteString(strconv.Itoa(len(tr.Matchers)))
+	sb.WriteString(" 个 Matcher")
+	for i, mt := range tr.Matchers {
+		sb.WriteString("\n")
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteString(". ")
+		sb.WriteString(mt.label())
+		sb.WriteString(" (priority=")
+		sb.WriteString(strconv.Itoa(mt.Priority))
+		sb.WriteString(") ")
+		switch {
+		case mt.Triggered:
+			sb.WriteString("已触发")
+		case mt.StoppedAt != "":
+			sb.WriteString("停止于 ")
+			sb.WriteString(mt.StoppedAt)
+		default:
+			sb.WriteString("未完成")
+		}
+		if mt.Break {
+			sb.WriteString(", Break")
+		}
+		for _, s := range mt.Steps {
+			sb.WriteString("\n   - ")
+			sb.WriteString(s.Stage)
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(s.Index))
+			sb.WriteString("] ")
+			sb.WriteString(s.Rule)
+			switch {
+			case s.Timeout:
+				sb.WriteString(" 超时")
+			case s.Passed:
+				sb.WriteString(" 通过")
+			default:
+				sb.WriteString(" 未通过")
+			}
+			sb.WriteString(" ")
+			sb.WriteString(s.Duration.String())
+		}
+	}
+	if tr.BlockedBy != "" {
+		sb.WriteString("\n后续 Matcher 被 ")
+		sb.WriteString(tr.BlockedBy)
+		sb.WriteString(" 阻断")
+	}
+	return sb.String()
+}
+
+// ruleName 返回 Rule 的函数名, 闭包返回其构造函数名
+func ruleName(r Rule) string {
+	if r == nil {
+		return "<nil>"
+	}
+	f := runtime.FuncForPC(reflect.ValueOf(r).Pointer())
+	if f == nil {
+		return "<unknown>"
+	}
+	name := strings.TrimSuffix(f.Name(), "-fm") // method value
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	// github.com/wdvxdr1123/ZeroBot.CommandRule.func1 -> ZeroBot.CommandRule
+	for {
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 || !strings.HasPrefix(name[i+1:], "func") {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,44 @@
+package zero
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+func TestEventTrace(t *testing.T) {
+	e := New().SetName("trace")
+	e.UsePreHandler(func(ctx *Ctx) bool { return true })
+	rejected := &Matcher{Name: "rejected", Type: Type("message"), Engine: e,
+		Rules: []Rule{OnlyToMe}, Handler: func(ctx *Ctx) {}}
+	blocker := &Matcher{Name: "blocker", Type: Type("message"), Engine: e, Block: true,
+		Rules: []Rule{KeywordRule("hi")}, Handler: func(ctx *Ctx) {}}
+	never := &Matcher{Name: "never", Type: Type("message"), Engine: e, Handler: func(ctx *Ctx) {}}
+
+	ctx := fakeCtx(message.Message{message.Text("hi")})
+	ctx.Event.PostType = "message"
+	id := message.NewMessageIDFromInteger(1919810)
+	ctx.trace = newEventTrace(ctx.Event, id)
+	match(ctx, []*Matcher{rejected, blocker, never}, time.Second)
+
+	tr := GetEventTrace(id)
+	assert.NotSame(t, ctx.trace, tr, "snapshot, not the live trace")
+	assert.Equal(t, ctx.trace.String(), tr.String())
+	assert.Equal(t, tr.String(), ctx.Trace().String())
+	assert.Len(t, tr.Matchers, 2)
+	assert.Equal(t, "rule[0]", tr.Matchers[0].StoppedAt)
+	assert.False(t, tr.Matchers[0].Triggered)
+	assert.Equal(t, "ZeroBot.OnlyToMe", tr.Matchers[0].Steps[1].Rule)
+	assert.True(t, tr.Matchers[1].Triggered)
+	assert.Equal(t, "ZeroBot.KeywordRule", tr.Matchers[1].Steps[1].Rule)
+	assert.Equal(t, "trace/blocker", tr.BlockedBy)
+	assert.True(t, strings.HasSuffix(tr.String(), "后续 Matcher 被 trace/blocker 阻断"))
+
+	ctx.trace.begin(never)
+	assert.Len(t, tr.Matchers, 2, "snapshot not affected by later matching")
+	assert.Nil(t, GetEventTrace(message.NewMessageIDFromInteger(114514)))
+}
@@ -0,0 +1,403 @@
+// Package metrics 提供无外部依赖的计数器、仪表与直方图,
+// 并以 Prometheus 文本格式导出
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认直方图分桶 (单位: 秒)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 240}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, old := range r.collectors {
+		if old.name() == c.name() {
+			panic("metrics: duplicate metric " + c.name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo 以 Prometheus 文本格式写出所有指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	cs := make([]collector, len(r.collectors))
+	copy(cs, r.collectors)
+	r.mu.RUnlock()
+	sort.Slice(cs, func(i, j int) bool { return cs[i].name() < cs[j].name() })
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range cs {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP impls http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// desc 指标描述
+type desc struct {
+	fqName string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) name() string { return d.fqName }
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	w.WriteString("# HELP ")
+	w.WriteString(d.fqName)
+	w.WriteByte(' ')
+	w.WriteString(strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	w.WriteString("\n# TYPE ")
+	w.WriteString(d.fqName)
+	w.WriteByte(' ')
+	w.WriteString(d.typ)
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// writeSample 写出一行样本, extra 为附加的标签对 (如 le)
+func writeSample(w *bufio.Writer, name string, labels, values []string, extra []string, v float64) {
+	w.WriteString(name)
+	if len(labels)+len(extra) > 0 {
+		w.WriteByte('{')
+		first := true
+		put := func(k, v string) {
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			w.WriteString(k)
+			w.WriteString(`="`)
+			w.WriteString(labelEscaper.Replace(v))
+			w.WriteByte('"')
+		}
+		for i, l := range labels {
+			put(l, values[i])
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			put(extra[i], extra[i+1])
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// atomicFloat 原子浮点数
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		n := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&f.bits, old, n) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// vec 按标签值分组保存子指标
+type vec[T any] struct {
+	mu       sync.RWMutex
+	children map[string]*child[T]
+	newT     func() *T
+}
+
+type child[T any] struct {
+	values []string
+	v      *T
+}
+
+func (v *vec[T]) with(n int, values []string) *T {
+	if len(values) != n {
+		panic("metrics: inconsistent label cardinality")
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c.v
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok = v.children[key]; ok {
+		return c.v
+	}
+	if v.children == nil {
+		v.children = make(map[string]*child[T])
+	}
+	c = &child[T]{values: append([]string(nil), values...), v: v.newT()}
+	v.children[key] = c
+	return c.v
+}
+
+// sorted 按标签值排序返回所有子指标
+func (v *vec[T]) sorted() []*child[T] {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for k := range v.children {
+		keys = append(keys, k)
+	}
+	cs := make([]*child[T], 0, len(keys))
+	sort.Strings(keys)
+	for _, k := range keys {
+		cs = append(cs, v.children[k])
+	}
+	v.mu.RUnlock()
+	return cs
+}
+
+// Counter 单调递增计数器
+type Counter struct {
+	v atomicFloat
+}
+
+// Inc 计数加一
+func (c *Counter) Inc() { c.v.add(1) }
+
+// Add 计数加 v, v 不可为负
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.v.add(v)
+}
+
+// Value 当前计数
+func (c *Counter) Value() float64 { return c.v.load() }
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	desc
+	vec[Counter]
+}
+
+// NewCounterVec 在注册表中创建带标签的计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc: desc{fqName: name, help: help, typ: "counter", labels: labels},
+		vec:  vec[Counter]{newT: func() *Counter { return &Counter{} }},
+	}
+	r.register(c)
+	return c
+}
+
+// With 获取对应标签值的计数器
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.with(len(c.labels), values)
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, ch := range c.sorted() {
+		writeSample(w, c.fqName, c.labels, ch.values, nil, ch.v.Value())
+	}
+}
+
+// Gauge 可增可减的仪表
+type Gauge struct {
+	v atomicFloat
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(v float64) { g.v.set(v) }
+
+// Add 当前值加 v
+func (g *Gauge) Add(v float64) { g.v.add(v) }
+
+// Inc 当前值加一
+func (g *Gauge) Inc() { g.v.add(1) }
+
+// Dec 当前值减一
+func (g *Gauge) Dec() { g.v.add(-1) }
+
+// Value 当前值
+func (g *Gauge) Value() float64 { return g.v.load() }
+
+// GaugeVec 带标签的仪表
+type GaugeVec struct {
+	desc
+	vec[Gauge]
+}
+
+// NewGaugeVec 在注册表中创建带标签的仪表
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		desc: desc{fqName: name, help: help, typ: "gauge", labels: labels},
+		vec:  vec[Gauge]{newT: func() *Gauge { return &Gauge{} }},
+	}
+	r.register(g)
+	return g
+}
+
+// With 获取对应标签值的仪表
+func (g *GaugeVec) With(values ...string) *Gauge {
+	return g.with(len(g.labels), values)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	for _, ch := range g.sorted() {
+		writeSample(w, g.fqName, g.labels, ch.values, nil, ch.v.Value())
+	}
+}
+
+// GaugeFunc 在导出时才计算值的仪表
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc 在注册表中创建导出时计算的仪表
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{
+		desc: desc{fqName: name, help: help, typ: "gauge"},
+		fn:   fn,
+	}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	writeSample(w, g.fqName, nil, nil, nil, g.fn())
+}
+
+// Histogram 直方图
+type Histogram struct {
+	upper  []float64
+	counts []uint64
+	count  uint64
+	sum    atomicFloat
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+	if i < len(h.counts) {
+		atomic.AddUint64(&h.counts[i], 1)
+	}
+	h.sum.add(v)
+	atomic.AddUint64(&h.count, 1)
+}
+
+// Count 观测次数
+func (h *Histogram) Count() uint64 { return atomic.LoadUint64(&h.count) }
+
+// Sum 观测值之和
+func (h *Histogram) Sum() float64 { return h.sum.load() }
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	desc
+	vec[Histogram]
+	buckets []float64
+}
+
+// NewHistogramVec 在注册表中创建带标签的直方图, buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		desc:    desc{fqName: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+	}
+	h.newT = func() *Histogram {
+		return &Histogram{upper: h.buckets, counts: make([]uint64, len(h.buckets))}
+	}
+	r.register(h)
+	return h
+}
+
+// With 获取对应标签值的直方图
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.with(len(h.labels), values)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	for _, ch := range h.sorted() {
+		var cum uint64
+		for i, upper := range h.buckets {
+			cum += atomic.LoadUint64(&ch.v.counts[i])
+			writeSample(w, h.fqName+"_bucket", h.labels, ch.values, []string{"le", formatFloat(upper)}, float64(cum))
+		}
+		count := ch.v.Count()
+		writeSample(w, h.fqName+"_bucket", h.labels, ch.values, []string{"le", "+Inf"}, float64(count))
+		writeSample(w, h.fqName+"_sum", h.labels, ch.values, nil, ch.v.Sum())
+		writeSample(w, h.fqName+"_count", h.labels, ch.values, nil, float64(count))
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "test counter", "action")
+	c.With("send_msg").Inc()
+	c.With("send_msg").Add(2)
+	c.With(`a"b`).Inc()
+	h := r.NewHistogramVec("test_seconds", "test histogram", []float64{1, 0.1}, "stage")
+	h.With("rule").Observe(0.05)
+	h.With("rule").Observe(0.5)
+	h.With("rule").Observe(5)
+	r.NewGaugeFunc("test_bots", "test gauge", func() float64 { return 2 })
+
+	sb := strings.Builder{}
+	_, err := r.WriteTo(&sb)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP test_bots test gauge
+# TYPE test_bots gauge
+test_bots 2
+# HELP test_seconds test histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{stage="rule",le="0.1"} 1
+test_seconds_bucket{stage="rule",le="1"} 2
+test_seconds_bucket{stage="rule",le="+Inf"} 3
+test_seconds_sum{stage="rule"} 5.55
+test_seconds_count{stage="rule"} 3
+# HELP test_total test counter
+# TYPE test_total counter
+test_total{action="a\"b"} 1
+test_total{action="send_msg"} 3
+`, sb.String())
+}
+
+func TestRegistry_Duplicate(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup", "")
+	assert.Panics(t, func() { r.NewGaugeVec("dup", "") })
+	assert.Panics(t, func() { r.NewCounterVec("x", "", "a").With() })
+}
@@ -0,0 +1,291 @@
+//go:build ignore
+
+// gen 由 Perl Unicode::Collate 的 CJK/Pinyin.pm 与 CJK/GB2312.pm 生成 table.go
+//
+//	go run gen.go /usr/share/perl/5.36.0/Unicode/Collate/CJK
+//
+// Pinyin.pm 按读音排列汉字, 每行为一组同音同调的字, 但不含拼音本身,
+// 因此以 seeds 中读音确定的字为每组标注拼音: 组内含有某音节的字时该组为此音节,
+// 两侧为同一音节的未标注组也归入该音节, 其余无法确定的组忽略, fixes 中的字直接指定。
+// 只输出 GB2312 中的汉字。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// seeds 音节 -> 读音确定的常用字 (避开多音字)
+var seeds = strings.Fields(`
+a:啊 ai:哎爱矮挨哀 an:安按暗岸俺 ang:昂肮盎 ao:奥傲熬袄
+ba:八巴拔把爸吧 bai:白百摆败拜 ban:班般板办半 bang:帮邦绑棒榜 bao:包宝抱报
+bei:杯北背被悲 ben:奔本笨 beng:崩甭蹦泵 bi:逼鼻比必闭 bian:边变遍 biao:标表彪
+bie:别憋瘪 bin:宾滨鬓 bing:冰兵饼病并 bo:波播博 bu:不布步部补
+ca:擦 cai:猜才采菜财 can:残惨灿餐 cang:仓苍舱 cao:操草曹槽 ce:策册测厕侧 cen:岑
+ceng:层蹭 cha:插茶查岔 chai:拆柴 chan:产缠馋颤 chang:常场唱厂昌 chao:超潮吵炒
+che:扯彻撤 chen:陈尘沉晨衬 cheng:成城程称乘 chi:吃池迟尺赤 chong:冲虫宠
+chou:抽愁丑臭仇 chu:出除初处础 chuai:揣踹 chuan:穿船串川 chuang:窗床创闯
+chui:吹垂锤 chun:春纯唇蠢 chuo:戳绰 ci:词此次刺瓷 cong:从聪葱丛 cou:凑
+cu:粗促醋簇 cuan:窜篡蹿 cui:催脆翠崔 cun:村存寸 cuo:错搓措挫
+da:达打答搭 dai:带代呆袋待 dan:但担蛋胆 dang:当党挡荡 dao:到道刀岛倒 de:得德
+deng:等灯登邓凳 di:低第敌底 dian:点电店典颠 diao:掉钓雕 die:跌爹叠碟
+ding:定丁顶钉订 diu:丢 dong:东动冬懂洞 dou:斗豆抖陡 du:读毒独 duan:段短断端锻
+dui:对堆队兑 dun:吨顿蹲盾 duo:多朵夺躲舵
+e:饿鹅额俄 en:恩 er:二而儿耳尔
+fa:发法罚乏伐 fan:反饭翻凡犯 fang:方放房防访 fei:飞非肥费废 fen:分粉份坟奋
+feng:风封丰冯奉 fo:佛 fu:夫福父付复
+ga:嘎尬 gai:该改盖概钙 gan:干感赶敢甘 gang:刚钢港岗杠 gao:高搞告稿糕
+ge:个歌哥格各 gen:跟根亘 geng:更耕耿梗 gong:工公共供宫 gou:够狗沟构购
+gu:古谷骨故顾 gua:瓜挂刮寡 guai:怪乖拐 guan:关管观惯官 guang:光广逛
+gui:贵鬼归规跪 gun:滚棍 guo:国过果锅郭
+ha:哈 hai:孩海害亥 han:汉喊含寒汗 hang:航杭 hao:好号毫豪耗 he:和喝河何贺
+hei:黑嘿 hen:很恨狠痕 heng:横哼恒衡 hong:红洪哄轰 hou:后候厚猴吼
+hu:湖呼虎户胡 hua:花话化华划 huai:坏怀淮槐 huan:欢换环缓患 huang:黄皇慌晃谎
+hui:回灰挥毁 hun:婚混魂浑昏 huo:活火或货获
+ji:机几记及急 jia:家加假价甲 jian:见间减件坚 jiang:将江讲奖 jiao:叫教交脚
+jie:接节姐借街 jin:今进近金紧 jing:经京精静景 jiong:窘炯 jiu:就九酒旧救
+ju:局居举句具 juan:卷捐绢倦 jue:决绝掘 jun:军君均俊菌
+ka:咖 kai:开凯慨 kan:看刊砍坎 kang:康抗扛炕 kao:考靠烤 ke:可科客课渴
+ken:肯啃垦恳 keng:坑 kong:空孔控恐 kou:口扣抠寇 ku:苦哭库裤酷
+kua:夸跨垮挎 kuai:快块筷 kuan:宽款 kuang:况矿框狂筐 kui:亏愧葵奎
+kun:困昆捆坤 kuo:扩阔括廓
+la:拉啦腊辣蜡 lai:来赖莱 lan:兰蓝烂懒拦 lang:浪狼朗郎廊 lao:老劳牢捞涝 le:勒
+lei:类累雷泪垒 leng:冷棱愣 li:里力离理立 lian:连脸练恋怜 liang:两量亮凉良
+liao:料聊疗辽 lie:列烈裂猎劣 lin:林临邻淋吝 ling:领另零令铃 liu:六流留刘柳
+long:龙隆拢笼垄 lou:楼漏搂陋 lu:路陆录炉鲁 lv:绿律旅虑吕 luan:乱卵
+lun:论轮伦 luo:罗洛裸锣
+ma:妈马吗骂麻 mai:买卖麦迈 man:满慢漫蛮馒 mang:忙盲茫莽 mao:毛猫冒帽茂 me:么
+mei:美妹每煤 men:们门闷 meng:梦猛蒙盟 mi:米密迷蜜 mian:面棉免眠
+miao:秒苗庙妙描 mie:灭蔑 min:民敏皿 ming:名明命鸣 miu:谬 mo:摸末磨莫
+mou:某谋 mu:木目母幕墓
+na:那拿哪纳 nai:奶耐乃 nan:男南难 nang:囊 nao:脑闹恼挠 nei:内馁 nen:嫩 neng:能
+ni:你泥尼逆腻 nian:年念碾 niang:娘酿 niao:鸟尿 nie:捏聂 nin:您 ning:宁凝拧
+niu:牛扭纽 nong:农浓 nu:努奴怒 nv:女 nuan:暖 nve:虐疟 nuo:挪诺懦
+o:哦 ou:欧偶呕
+pa:怕爬帕趴 pai:拍派排牌 pan:盘判盼攀 pang:旁胖庞 pao:跑泡抛袍 pei:陪配赔佩
+pen:喷盆 peng:朋碰棚捧 pi:皮批匹屁劈 pian:片篇骗偏 piao:票飘漂 pie:撇瞥
+pin:品贫拼聘 ping:平评瓶凭 po:破坡婆泼 pou:剖 pu:普铺扑葡
+qi:起七期气其 qia:恰掐洽 qian:前千钱浅欠 qiang:强枪墙抢 qiao:桥巧敲瞧悄
+qie:切且窃 qin:亲琴勤秦寝 qing:请轻清情庆 qiong:穷琼 qiu:秋求球丘
+qu:去取曲趣 quan:全权劝泉 que:却缺确雀 qun:群裙
+ran:然染燃 rang:让嚷壤 rao:绕扰饶 re:热惹 ren:人认任忍仁 reng:仍扔 ri:日
+rong:容荣融绒 rou:肉柔揉 ru:如入乳辱 ruan:软 rui:瑞锐蕊 run:润闰 ruo:若弱
+sa:撒洒萨 sai:赛腮 san:三散伞 sang:桑丧嗓 sao:扫嫂骚 se:色涩 sen:森 seng:僧
+sha:杀沙傻啥 shai:晒筛 shan:山善闪衫 shang:上商伤赏 shao:少烧绍勺
+she:社设蛇舌舍 shen:身深神审 sheng:生声剩圣 shi:是十事时使 shou:手收受首寿
+shu:书数树输 shua:刷耍 shuai:帅摔甩 shuan:拴涮 shuang:双爽霜 shui:水谁睡税
+shun:顺吮 shuo:硕 si:四死思丝寺 song:送松宋耸 sou:搜艘 su:速素苏俗
+suan:算酸蒜 sui:随岁碎虽 sun:孙损笋 suo:所索锁缩
+ta:他她它塔踏 tai:太台态抬 tan:谈探叹坦滩 tang:汤糖躺趟堂 tao:逃套桃讨掏 te:特
+teng:疼腾藤 ti:提题体替踢 tian:天田甜填添 tiao:条跳挑 tie:铁贴帖 ting:听停挺庭
+tong:同通痛统桶 tou:头投偷透 tu:图土突途吐 tuan:团 tui:推腿退 tun:吞屯
+tuo:拖脱托妥
+wa:挖哇娃瓦袜 wai:外歪 wan:完万晚玩弯 wang:王望网往忘 wei:为位未围危
+wen:问文闻稳温 weng:翁嗡 wo:我握窝卧 wu:无五物屋务
+xi:西洗喜习 xia:下夏虾峡 xian:先现线鲜显 xiang:想向象香项 xiao:小笑消晓
+xie:些写谢鞋协 xin:新心信欣辛 xing:星性醒形 xiong:兄熊胸雄 xiu:修秀休袖
+xu:需许续虚序 xuan:选宣悬旋 xue:学雪穴 xun:寻讯训迅
+ya:压呀牙雅亚 yan:眼言烟演验 yang:样阳养洋央 yao:要药咬摇腰 ye:也夜业爷
+yi:一以已意衣 yin:因音银引印 ying:应英营影硬 yo:哟 yong:用永拥勇涌
+you:有又由油优 yu:于与语鱼遇 yuan:元远原院圆 yue:月越 yun:云运允晕
+za:杂砸 zai:在再载灾 zan:咱赞暂 zang:脏葬赃 zao:早造遭澡 ze:则责泽择 zei:贼
+zen:怎 zeng:增赠 zha:炸渣眨 zhai:窄摘宅债 zhan:站战展占沾 zhang:张章涨丈掌
+zhao:找照招赵 zhe:这者折哲 zhen:真针阵镇枕 zheng:正整证争政 zhi:只之直知制
+zhong:中钟众忠肿 zhou:周州洲皱粥 zhu:主住注助猪 zhua:抓 zhuai:拽
+zhuan:转专砖赚 zhuang:装状壮撞庄 zhui:追坠 zhun:准 zhuo:桌捉卓 zi:子字自资紫
+zong:总宗纵棕 zou:走奏揍 zu:组族足租阻 zuan:钻 zui:最嘴醉罪 zun:尊遵
+zuo:做作坐左昨
+`)
+
+// fixes 数据中位置有误或无法推断的字, 直接指定音节
+var fixes = strings.Fields(`lve:略掠 ka:卡 bu:卜`)
+
+// readData 读取 Perl 模块 __DATA__ 段的各行
+func readData(path string) [][]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	_, data, _ = bytes.Cut(data, []byte("__DATA__\n"))
+	data, _, _ = bytes.Cut(data, []byte("__END__"))
+	var lines [][]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		if f := strings.Fields(sc.Text()); len(f) > 0 {
+			lines = append(lines, f)
+		}
+	}
+	return lines
+}
+
+func parseRune(s string) rune {
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		panic(err)
+	}
+	return rune(n)
+}
+
+func concat(lines [][]rune) []rune {
+	var r []rune
+	for _, l := range lines {
+		r = append(r, l...)
+	}
+	return r
+}
+
+func main() {
+	dir := os.Args[1]
+	gb := map[rune]bool{}
+	for _, l := range readData(filepath.Join(dir, "GB2312.pm")) {
+		for _, c := range l {
+			gb[parseRune(c)] = true
+		}
+	}
+
+	// 同音同调的组, 每行至多 10 个字, 满 10 个时下一行通常属于同一组
+	var groups [][][]rune
+	var cur [][]rune
+	for _, l := range readData(filepath.Join(dir, "Pinyin.pm")) {
+		if strings.Contains(l[0], "-") { // 字母分隔
+			if cur != nil {
+				groups = append(groups, cur)
+				cur = nil
+			}
+			continue
+		}
+		line := make([]rune, len(l))
+		for i, c := range l {
+			line[i] = parseRune(c)
+		}
+		cur = append(cur, line)
+		if len(l) < 10 {
+			groups = append(groups, cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		groups = append(groups, cur)
+	}
+
+	seed := map[rune]string{}
+	for _, s := range seeds {
+		syl, chars, _ := strings.Cut(s, ":")
+		for _, c := range chars {
+			seed[c] = syl
+		}
+	}
+	// 组内出现两个音节时, 在两者之间的行处拆分 (满 10 个的行恰好是一组的末尾)
+	var split [][]rune
+	var labels []string
+	bad := false
+	for _, g := range groups {
+		start, label := 0, ""
+		for i, line := range g {
+			for _, c := range line {
+				syl, ok := seed[c]
+				if !ok || syl == label {
+					continue
+				}
+				if label != "" {
+					if i == start {
+						fmt.Fprintf(os.Stderr, "%s and %s in one line (%c)\n", label, syl, c)
+						bad = true
+						continue
+					}
+					split = append(split, concat(g[start:i]))
+					labels = append(labels, label)
+					start = i
+				}
+				label = syl
+			}
+		}
+		split = append(split, concat(g[start:]))
+		labels = append(labels, label)
+	}
+	// 同一音节的标注组须连续
+	last := map[string]int{}
+	for i, l := range labels {
+		if l == "" {
+			continue
+		}
+		if j, ok := last[l]; ok {
+			for k := j + 1; k < i; k++ {
+				if labels[k] != "" && labels[k] != l {
+					fmt.Fprintf(os.Stderr, "%s: group %d labeled %s in between\n", l, k, labels[k])
+					bad = true
+				}
+			}
+		}
+		last[l] = i
+	}
+	if bad {
+		os.Exit(1)
+	}
+	// 填充两侧为同一音节的未标注组
+	prev := -1
+	for i, l := range labels {
+		if l == "" {
+			continue
+		}
+		if prev >= 0 && labels[prev] == l {
+			for k := prev + 1; k < i; k++ {
+				labels[k] = l
+			}
+		}
+		prev = i
+	}
+
+	table := map[string][]rune{}
+	seen := map[rune]bool{}
+	for _, f := range fixes {
+		syl, chars, _ := strings.Cut(f, ":")
+		for _, c := range chars {
+			table[syl] = append(table[syl], c)
+			seen[c] = true
+		}
+	}
+	total, skipped := 0, 0
+	for i, g := range split {
+		for _, c := range g {
+			if !gb[c] || seen[c] {
+				continue
+			}
+			seen[c] = true
+			if labels[i] == "" {
+				skipped++
+				continue
+			}
+			table[labels[i]] = append(table[labels[i]], c)
+			total++
+		}
+	}
+	fmt.Fprintln(os.Stderr, "chars:", total, "skipped:", skipped, "syllables:", len(table))
+
+	syllables := make([]string, 0, len(table))
+	for s := range table {
+		syllables = append(syllables, s)
+	}
+	sort.Strings(syllables)
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\npackage pinyin\n\n")
+	buf.WriteString("// table 音节 -> GB2312 中该读音的汉字\nvar table = map[string]string{\n")
+	for _, s := range syllables {
+		fmt.Fprintf(&buf, "%q: %q,\n", s, string(table[s]))
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile("table.go", src, 0o644); err != nil {
+		panic(err)
+	}
+}
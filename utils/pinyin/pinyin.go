@@ -0,0 +1,48 @@
+// Package pinyin 提供不带声调的汉字拼音转换, 用于比较中文命令的读音
+//
+// 仅收录 GB2312 中的汉字, 多音字取最常用的读音, ü 写作 v; 未收录的字原样保留。
+// 数据由 gen.go 生成。
+package pinyin
+
+//go:generate go run gen.go /usr/share/perl/5.36.0/Unicode/Collate/CJK
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	dict     map[rune]string
+	dictOnce sync.Once
+)
+
+func load() {
+	dict = make(map[rune]string, 6400)
+	for syllable, chars := range table {
+		for _, c := range chars {
+			dict[c] = syllable
+		}
+	}
+}
+
+// Lookup 返回汉字 r 的拼音
+func Lookup(r rune) (string, bool) {
+	dictOnce.Do(load)
+	s, ok := dict[r]
+	return s, ok
+}
+
+// Convert 将 s 中的汉字转换为拼音并直接连接, 其余字符原样保留
+//
+//	Convert("帮助") == "bangzhu"
+func Convert(s string) string {
+	sb := strings.Builder{}
+	for _, r := range s {
+		if p, ok := Lookup(r); ok {
+			sb.WriteString(p)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
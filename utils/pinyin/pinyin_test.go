@@ -0,0 +1,21 @@
+package pinyin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	assert.Equal(t, "bangzhu", Convert("帮助"))
+	assert.Equal(t, Convert("帮助"), Convert("帮注"))
+	assert.Equal(t, "/qiandao 1", Convert("/签到 1"))
+	assert.Equal(t, "lvmao", Convert("绿帽"))
+	assert.Equal(t, "zhuzhuzhu", Convert("祝竹逐"))
+
+	p, ok := Lookup('略')
+	assert.True(t, ok)
+	assert.Equal(t, "lve", p)
+	_, ok = Lookup('a')
+	assert.False(t, ok)
+}